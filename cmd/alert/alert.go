@@ -3,14 +3,28 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"site-monitor/internal/alert"
 	"site-monitor/internal/config"
+	"site-monitor/internal/storage"
 	"site-monitor/internal/telegram"
+	"site-monitor/pkg/health"
 	"site-monitor/pkg/logger"
+	"site-monitor/pkg/metrics"
+	"site-monitor/pkg/tracing"
 	"site-monitor/pkg/utils"
 )
 
+const (
+	defaultConfigPath  = "configs/alert.yaml"
+	reloadPollInterval = 5 * time.Second
+)
+
 func main() {
 	log, err := logger.SetupLogger()
 	if err != nil {
@@ -25,20 +39,92 @@ func main() {
 		return
 	}
 
+	log, err = logger.New(alertCfg.Logging, "alert-service")
+	if err != nil {
+		fmt.Println("Failed to configure logger:", err)
+		return
+	}
+	defer log.Sync()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), alertCfg.Tracing, "alert-service")
+	if err != nil {
+		log.Sugar.Errorw("Failed to setup tracing", "error", err)
+		return
+	}
+	defer shutdownTracing(context.Background())
+
+	pgClient, err := storage.NewPostgresStorage(alertCfg.Postgres.DSN)
+	if err != nil {
+		log.Sugar.Errorw("Failed to setup Postgres", "error", err)
+		return
+	}
+
 	tgClient := telegram.NewClient(alertCfg.Telegram.BotToken, alertCfg.Telegram.ChatID)
-	consumer := alert.NewAlertConsumer(alertCfg, log, tgClient)
+	escalator := alert.NewEscalator(pgClient, tgClient, log, time.Duration(alertCfg.Escalation.Interval)*time.Second)
+	consumer := alert.NewAlertConsumer(alertCfg, log, pgClient, escalator, defaultNotifiers(alertCfg, tgClient, log)...)
 	defer consumer.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	utils.SetupGracefulShutdown(cancel, log)
+
+	go escalator.Run(ctx)
+	go consumer.PruneResults(ctx)
+
+	utils.SetupConfigReload(ctx, config.ResolvePath(defaultConfigPath), reloadPollInterval, func() error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := log.SetLevel(cfg.Logging.Level); err != nil {
+			return err
+		}
+		tg := telegram.NewClient(cfg.Telegram.BotToken, cfg.Telegram.ChatID)
+		consumer.UpdateConfig(cfg, defaultNotifiers(cfg, tg, log)...)
+		escalator.Update(tg, time.Duration(cfg.Escalation.Interval)*time.Second)
+		return nil
+	}, log)
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", alertCfg.Server.Port),
+		Handler: newAdminRouter(consumer, pgClient, alertCfg.Server.AdminToken, log),
+	}
+	go utils.RunHTTPServer(ctx, srv, log)
+
 	consumer.Consume(ctx)
 }
 
 func loadConfig() (config.AlertConfig, error) {
 	var cfg config.AlertConfig
-	if err := config.LoadConfig("configs/alert.yaml", &cfg); err != nil {
+	if err := config.Load(defaultConfigPath, &cfg); err != nil {
 		return cfg, err
 	}
 	return cfg, nil
 }
+
+func defaultNotifiers(cfg config.AlertConfig, tg *telegram.Client, log *logger.Logger) []alert.Notifier {
+	if !cfg.TelegramEnabled() {
+		log.Sugar.Warnw("Telegram bot token is not set, alerts are delivered only through routes")
+		return nil
+	}
+	return []alert.Notifier{tg}
+}
+
+func newAdminRouter(consumer *alert.AlertConsumer, store storage.Storage, adminToken string, log *logger.Logger) http.Handler {
+	r := chi.NewRouter()
+
+	r.Handle("/metrics", promhttp.HandlerFor(metrics.AlertRegistry, promhttp.HandlerOpts{}))
+	if adminToken != "" {
+		r.With(utils.RequireToken(adminToken)).Handle("/admin/log-level", log.LevelHandler())
+	}
+
+	r.Handle("/livez", health.Liveness(log))
+	r.Handle("/readyz", health.Readiness(log, health.DefaultTimeout, map[string]health.Check{
+		"kafka":     health.Kafka(consumer.Brokers()),
+		"redis":     consumer.PingRedis,
+		"postgres":  store.Ping,
+		"notifiers": consumer.PingNotifiers,
+	}))
+
+	return r
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"site-monitor/internal/auth"
+	"site-monitor/internal/config"
+	"site-monitor/internal/storage"
+)
+
+// apikey manages CRUD API keys directly in Postgres. It is meant for
+// bootstrapping the first admin key and service keys such as the checker's;
+// after that keys can be managed through /api-keys.
+func main() {
+	name := flag.String("name", "", "name of the new key, e.g. checker")
+	role := flag.String("role", auth.RoleViewer, "role of the new key: viewer, editor, admin or service")
+	list := flag.Bool("list", false, "list existing keys")
+	revoke := flag.String("revoke", "", "revoke the key with this ID")
+	org := flag.String("org", "", "organization ID; without it keys are platform-wide and -list shows all keys")
+	flag.Parse()
+
+	orgID := storage.AllOrgs
+	if *org != "" {
+		orgID = *org
+	}
+
+	var cfg config.CrudConfig
+	if err := config.Load("configs/crud.yaml", &cfg); err != nil {
+		fail("Failed to load config:", err)
+	}
+
+	store, err := storage.NewPostgresStorage(cfg.Postgres.DSN)
+	if err != nil {
+		fail("Failed to connect to Postgres:", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch {
+	case *list:
+		keys, err := store.GetAPIKeys(ctx, orgID)
+		if err != nil {
+			fail("Failed to list keys:", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tORG\tNAME\tPREFIX\tROLE\tCREATED\tREVOKED")
+		for _, k := range keys {
+			revoked := "-"
+			if k.RevokedAt != nil {
+				revoked = k.RevokedAt.Format(time.RFC3339)
+			}
+			keyOrg := "*"
+			if k.OrgID != "" {
+				keyOrg = k.OrgID
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, keyOrg, k.Name, k.Prefix, k.Role,
+				k.CreatedAt.Format(time.RFC3339), revoked)
+		}
+		tw.Flush()
+	case *revoke != "":
+		if err := store.RevokeAPIKey(ctx, orgID, *revoke); err != nil {
+			fail("Failed to revoke key:", err)
+		}
+		fmt.Println("Revoked", *revoke)
+	default:
+		if *name == "" || !auth.ValidRole(*role) {
+			fmt.Fprintln(os.Stderr, "Usage: apikey [-org <id>] -name <name> [-role viewer|editor|admin|service] | -list | -revoke <id>")
+			os.Exit(2)
+		}
+		key, prefix, hash, err := auth.GenerateKey()
+		if err != nil {
+			fail("Failed to generate key:", err)
+		}
+		id, err := store.AddAPIKey(ctx, orgID, storage.APIKey{Name: *name, Prefix: prefix, Hash: hash, Role: *role})
+		if err != nil {
+			fail("Failed to store key:", err)
+		}
+		fmt.Fprintf(os.Stderr, "Created %s key %q with ID %s. It won't be shown again.\n", *role, *name, id)
+		fmt.Println(key)
+	}
+}
+
+func fail(msg string, err error) {
+	fmt.Fprintln(os.Stderr, msg, err)
+	os.Exit(1)
+}
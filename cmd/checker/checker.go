@@ -3,13 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 
 	"site-monitor/internal/checker"
 	"site-monitor/internal/config"
+	"site-monitor/pkg/health"
 	"site-monitor/pkg/logger"
+	"site-monitor/pkg/tracing"
 	"site-monitor/pkg/utils"
 )
 
+const (
+	defaultConfigPath  = "configs/checker.yaml"
+	reloadPollInterval = 5 * time.Second
+)
+
 func main() {
 	log, err := logger.SetupLogger()
 	if err != nil {
@@ -24,18 +35,67 @@ func main() {
 		return
 	}
 
+	log, err = logger.New(checkerCfg.Logging, "checker-service")
+	if err != nil {
+		fmt.Println("Failed to configure logger:", err)
+		return
+	}
+	defer log.Sync()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), checkerCfg.Tracing, "checker-service")
+	if err != nil {
+		log.Sugar.Errorw("Failed to setup tracing", "error", err)
+		return
+	}
+	defer shutdownTracing(context.Background())
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	utils.SetupGracefulShutdown(cancel, log)
 
 	c := checker.NewChecker(checkerCfg, log)
+
+	utils.SetupConfigReload(ctx, config.ResolvePath(defaultConfigPath), reloadPollInterval, func() error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := log.SetLevel(cfg.Logging.Level); err != nil {
+			return err
+		}
+		c.UpdateConfig(cfg)
+		return nil
+	}, log)
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", checkerCfg.Server.Port),
+		Handler: newAdminRouter(c, checkerCfg.Server.AdminToken, log),
+	}
+	go utils.RunHTTPServer(ctx, srv, log)
+
 	c.Run(ctx)
 }
 
 func loadConfig() (config.CheckerConfig, error) {
 	var cfg config.CheckerConfig
-	if err := config.LoadConfig("configs/checker.yaml", &cfg); err != nil {
+	if err := config.Load(defaultConfigPath, &cfg); err != nil {
 		return cfg, err
 	}
 	return cfg, nil
 }
+
+func newAdminRouter(c *checker.Checker, adminToken string, log *logger.Logger) http.Handler {
+	r := chi.NewRouter()
+	r.Handle("/metrics", c.MetricsHandler())
+	r.Handle("/livez", health.Liveness(log))
+	r.Handle("/readyz", health.Readiness(log, health.DefaultTimeout, c.ReadinessChecks()))
+	if adminToken != "" {
+		r.Group(func(r chi.Router) {
+			r.Use(utils.RequireToken(adminToken))
+			r.With(tracing.Middleware).Method(http.MethodPost, "/check", c.CheckHandler())
+			r.Handle("/admin/log-level", log.LevelHandler())
+			r.Handle("/probe", c.ProbeHandler())
+		})
+	}
+	return r
+}
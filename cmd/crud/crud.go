@@ -7,11 +7,17 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"site-monitor/internal/audit"
+	"site-monitor/internal/auth"
 	"site-monitor/internal/config"
 	"site-monitor/internal/crud"
 	"site-monitor/internal/storage"
+	"site-monitor/migrations"
 	"site-monitor/pkg/logger"
+	"site-monitor/pkg/metrics"
+	"site-monitor/pkg/tracing"
 	"site-monitor/pkg/utils"
 )
 
@@ -29,23 +35,49 @@ func main() {
 		return
 	}
 
+	log, err = logger.New(crudCfg.Logging, "crud-service")
+	if err != nil {
+		fmt.Println("Failed to configure logger:", err)
+		return
+	}
+	defer log.Sync()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), crudCfg.Tracing, "crud-service")
+	if err != nil {
+		log.Sugar.Errorw("Failed to setup tracing", "error", err)
+		return
+	}
+	defer shutdownTracing(context.Background())
+
 	pgClient, err := setupPostgres(crudCfg, log)
 	if err != nil {
 		log.Sugar.Errorw("Failed to setup Postgres", "error", err)
 		return
 	}
 
+	authenticator, err := setupAuth(crudCfg, pgClient, log)
+	if err != nil {
+		log.Sugar.Errorw("Failed to setup authentication", "error", err)
+		return
+	}
+
+	recorder := audit.NewRecorder(pgClient, crudCfg.Audit, log)
+	defer recorder.Close()
+	if crudCfg.Audit.StreamEnabled() {
+		log.Sugar.Infow("Streaming audit events to Kafka", "topic", crudCfg.Audit.Kafka.Topic)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	utils.SetupGracefulShutdown(cancel, log)
 
-	runCrudServer(ctx, crudCfg, pgClient, log)
+	runCrudServer(ctx, crudCfg, pgClient, authenticator, recorder, log)
 }
 
 func loadConfig() (config.CrudConfig, error) {
 	var crudCfg config.CrudConfig
 
-	if err := config.LoadConfig("configs/crud.yaml", &crudCfg); err != nil {
+	if err := config.Load("configs/crud.yaml", &crudCfg); err != nil {
 		return crudCfg, err
 	}
 
@@ -53,20 +85,54 @@ func loadConfig() (config.CrudConfig, error) {
 }
 
 func setupPostgres(crudCfg config.CrudConfig, log *logger.Logger) (storage.Storage, error) {
-	var client storage.Storage
 	client, err := storage.NewPostgresStorage(crudCfg.Postgres.DSN)
 	if err != nil {
 		log.Sugar.Errorw("Postgres connection failed", "error", err)
 		return nil, err
 	}
+
+	applied, err := client.Migrate(context.Background(), migrations.FS)
+	for _, name := range applied {
+		log.Sugar.Infow("Applied migration", "file", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
 	return client, nil
 }
 
-func runCrudServer(ctx context.Context, cfg config.CrudConfig, dbClient storage.Storage, log *logger.Logger) {
+func setupAuth(crudCfg config.CrudConfig, keys auth.KeyStore, log *logger.Logger) (*auth.Authenticator, error) {
+	if !crudCfg.Auth.Enabled {
+		log.Sugar.Warnw("Authentication is disabled, every request is treated as admin")
+		return auth.NewAuthenticator(false, keys, nil, log), nil
+	}
+
+	var verifier *auth.JWTVerifier
+	if crudCfg.Auth.JWT.Enabled() {
+		var err error
+		verifier, err = auth.NewJWTVerifier(context.Background(), crudCfg.Auth.JWT)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return auth.NewAuthenticator(true, keys, verifier, log), nil
+}
+
+func runCrudServer(ctx context.Context, cfg config.CrudConfig, dbClient storage.Storage, authenticator *auth.Authenticator,
+	recorder *audit.Recorder, log *logger.Logger) {
 	r := chi.NewRouter()
+	r.Use(crud.RequestID, tracing.Middleware, crud.AccessLog(log), crud.Recoverer(log))
 
-	crudHandler := crud.NewHandler(dbClient, log)
+	var checker *crud.CheckerClient
+	if cfg.Checker.URL != "" && cfg.Checker.Token != "" {
+		checker = crud.NewCheckerClient(cfg.Checker.URL, cfg.Checker.Token, time.Duration(cfg.Checker.Timeout)*time.Second)
+	} else {
+		log.Sugar.Warnw("checker.url or checker.token is not set, POST /sites/{id}/check is disabled")
+	}
+
+	crudHandler := crud.NewHandler(dbClient, authenticator, recorder, checker, log)
 	crudHandler.RegisterRoutes(r)
+	r.Handle("/metrics", promhttp.HandlerFor(metrics.CrudRegistry, promhttp.HandlerOpts{}))
 
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 	srv := &http.Server{
@@ -74,22 +140,5 @@ func runCrudServer(ctx context.Context, cfg config.CrudConfig, dbClient storage.
 		Handler: r,
 	}
 
-	go func() {
-		<-ctx.Done()
-
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if err := srv.Shutdown(shutdownCtx); err != nil {
-			log.Sugar.Errorw("HTTP server Shutdown failed", "error", err)
-		}
-	}()
-
-	log.Sugar.Infow("CRUD HTTP server started", "addr", srv.Addr)
-
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Sugar.Errorw("HTTP server ListenAndServe failed", "error", err)
-	}
-
-	log.Sugar.Infow("CRUD HTTP server stopped gracefully")
+	utils.RunHTTPServer(ctx, srv, log)
 }
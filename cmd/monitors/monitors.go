@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"site-monitor/internal/monitors"
+	"site-monitor/pkg/client"
+)
+
+// monitors keeps the sites of an organization in sync with a directory of
+// YAML definitions. "plan" shows what would change, "apply" does it. Sites
+// are tracked by their external ID, so a monitor keeps its history when its
+// URL or anything else in the file changes.
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "plan" && os.Args[1] != "apply") {
+		usage()
+	}
+	cmd := os.Args[1]
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	dir := fs.String("dir", "monitors", "directory with *.yaml monitor definitions")
+	prune := fs.Bool("prune", false, "delete sites that are not defined in -dir")
+	api := fs.String("api", envOr("SITEMON_API_URL", "http://localhost:8080"), "CRUD API URL")
+	apiKey := fs.String("api-key", os.Getenv("SITEMON_API_KEY"), "API key, editor role or higher for apply")
+	org := fs.String("org", "", "organization ID, for platform-wide keys")
+	timeout := fs.Duration("timeout", 5*time.Minute, "overall timeout")
+	fs.Parse(os.Args[2:])
+
+	defs, err := monitors.Load(*dir)
+	if err != nil {
+		fail("Invalid monitor definitions:", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	c := client.New(client.Config{URL: *api, APIKey: *apiKey, OrgID: *org})
+	if *org == "" {
+		// A platform-wide key lists every organization's sites: -prune
+		// would delete other tenants' sites and creates would land in the
+		// default organization.
+		me, err := c.WhoAmI(ctx)
+		if err != nil {
+			fail("Failed to check credentials:", err)
+		}
+		if me.Global {
+			fail("Refusing to run:", errors.New("the API key is platform-wide, pick an organization with -org"))
+		}
+	}
+	current, err := c.ListSites(ctx, nil)
+	if err != nil {
+		fail("Failed to list sites:", err)
+	}
+
+	plan := monitors.Diff(defs, current, *prune)
+	plan.Write(os.Stdout)
+	if cmd == "plan" || plan.Empty() {
+		return
+	}
+
+	fmt.Println()
+	if err := monitors.Apply(ctx, c, plan, os.Stdout); err != nil {
+		fail("Apply failed:", err)
+	}
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: monitors plan|apply [-dir <dir>] [-prune] [-api <url>] [-api-key <key>] [-org <id>]")
+	os.Exit(2)
+}
+
+func fail(msg string, err error) {
+	fmt.Fprintln(os.Stderr, msg, err)
+	os.Exit(1)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"site-monitor/internal/config"
+)
+
+func main() {
+	genKey := flag.Bool("genkey", false, "generate a new key for "+config.SecretKeyEnv)
+	flag.Parse()
+
+	if *genKey {
+		key, err := config.GenerateSecretKey()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to generate key:", err)
+			os.Exit(1)
+		}
+		fmt.Println(key)
+		return
+	}
+
+	plaintext, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && plaintext == "" {
+		fmt.Fprintln(os.Stderr, "Failed to read secret from stdin:", err)
+		os.Exit(1)
+	}
+
+	encrypted, err := config.EncryptSecret(strings.TrimRight(plaintext, "\r\n"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to encrypt secret:", err)
+		os.Exit(1)
+	}
+	fmt.Println(encrypted)
+}
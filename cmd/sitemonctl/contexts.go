@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const contextsEnv = "SITEMONCTL_CONFIG"
+
+// contextsFile is the local file with the API endpoints sitemonctl talks to,
+// one context per environment.
+type contextsFile struct {
+	Current  string                 `yaml:"current"`
+	Contexts map[string]*apiContext `yaml:"contexts"`
+
+	path string
+}
+
+type apiContext struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key,omitempty"`
+	Token  string `yaml:"token,omitempty"`
+	Org    string `yaml:"org,omitempty"`
+}
+
+// contextsPath is $SITEMONCTL_CONFIG, or sitemonctl/config.yaml in the
+// user's config directory.
+func contextsPath() (string, error) {
+	if p := os.Getenv(contextsEnv); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sitemonctl", "config.yaml"), nil
+}
+
+// loadContexts reads the contexts file. A missing file is an empty one.
+func loadContexts() (*contextsFile, error) {
+	path, err := contextsPath()
+	if err != nil {
+		return nil, err
+	}
+	f := &contextsFile{Contexts: map[string]*apiContext{}, path: path}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(raw, f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Contexts == nil {
+		f.Contexts = map[string]*apiContext{}
+	}
+	return f, nil
+}
+
+// save writes the file readable only by its owner, as it holds API keys.
+func (f *contextsFile) save() error {
+	raw, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, raw, 0o600)
+}
+
+// get returns the named context, or the current one for "".
+func (f *contextsFile) get(name string) (*apiContext, error) {
+	if name == "" {
+		name = f.Current
+	}
+	if name == "" {
+		return &apiContext{}, nil
+	}
+	ctx, ok := f.Contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("no context %q in %s", name, f.path)
+	}
+	return ctx, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"site-monitor/pkg/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// printer writes command results in the format picked with -o. JSON and
+// YAML carry the API's objects as they are; tables are for people.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) structured(v any) (bool, error) {
+	switch p.format {
+	case outputJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return true, enc.Encode(v)
+	case outputYAML:
+		return true, writeYAML(p.w, v)
+	default:
+		return false, nil
+	}
+}
+
+// writeYAML goes through JSON so that YAML keys match the API's field names
+// and keep their order.
+func writeYAML(w io.Writer, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle undoes the flow style and quoting that JSON input leaves on the
+// nodes; the encoder still quotes strings that would read as another type.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func (p printer) sites(sites []client.Site) error {
+	if ok, err := p.structured(sites); ok {
+		return err
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEXTERNAL ID\tURL\tACTIVE\tTEAM\tNAME")
+	for _, s := range sites {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\n", s.ID, dash(s.ExternalID), s.URL, s.Active, dash(s.Team), dash(s.Name))
+	}
+	return tw.Flush()
+}
+
+func (p printer) site(s *client.Site) error {
+	if ok, err := p.structured(s); ok {
+		return err
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	for _, f := range [][2]string{
+		{"ID", s.ID},
+		{"External ID", s.ExternalID},
+		{"URL", s.URL},
+		{"Active", strconv.FormatBool(s.Active)},
+		{"Name", s.Name},
+		{"Description", s.Description},
+		{"Owner", s.Owner},
+		{"Runbook", s.RunbookURL},
+		{"Team", s.Team},
+		{"Tags", strings.Join(s.Tags, ", ")},
+		{"Labels", labels(s.Labels)},
+		{"Version", strconv.FormatInt(s.Version, 10)},
+	} {
+		fmt.Fprintf(tw, "%s:\t%s\n", f[0], dash(f[1]))
+	}
+	return tw.Flush()
+}
+
+type statusOutput struct {
+	Status  *client.SiteStatus   `json:"status"`
+	Results []client.CheckResult `json:"results"`
+}
+
+func (p printer) status(st *client.SiteStatus, results []client.CheckResult) error {
+	if ok, err := p.structured(statusOutput{Status: st, Results: results}); ok {
+		return err
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Site:\t%s\n", st.SiteID)
+	fmt.Fprintf(tw, "URL:\t%s\n", st.URL)
+	fmt.Fprintf(tw, "State:\t%s\n", st.State)
+	if c := st.LastCheck; c != nil {
+		fmt.Fprintf(tw, "Last check:\t%s (%s ago)\n", c.CheckedAt.Format(time.RFC3339), time.Since(c.CheckedAt).Round(time.Second))
+	}
+	if inc := st.Incident; inc != nil {
+		fmt.Fprintf(tw, "Incident:\t%s, open since %s\n", inc.ID, inc.OpenedAt.Format(time.RFC3339))
+		if inc.AcknowledgedAt != nil {
+			fmt.Fprintf(tw, "Acknowledged:\tby %s at %s\n", inc.AcknowledgedBy, inc.AcknowledgedAt.Format(time.RFC3339))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(p.w)
+	return p.resultTable(results)
+}
+
+func (p printer) result(r *client.CheckResult) error {
+	if ok, err := p.structured(r); ok {
+		return err
+	}
+	return p.resultTable([]client.CheckResult{*r})
+}
+
+func (p printer) resultTable(results []client.CheckResult) error {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECKED AT\tUP\tSTATUS\tTIME\tERROR")
+	for _, r := range results {
+		up := strconv.FormatBool(r.Up)
+		if r.Maintenance {
+			up += " (maintenance)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d ms\t%s\n", r.CheckedAt.Format(time.RFC3339), up, r.Status, r.ResponseTimeMs, dash(r.Error))
+	}
+	return tw.Flush()
+}
+
+func labels(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
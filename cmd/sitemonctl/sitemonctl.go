@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"site-monitor/pkg/client"
+)
+
+const usageText = `Usage: sitemonctl [flags] <command> [args]
+
+Sites:
+  list [-active true|false] [-tag t]... [-label k[=v]]... [-url-contains s]
+  get <site>
+  add -url <url> [site flags]
+  update <site> [site flags]      change only the given fields
+  delete <site>
+  pause <site>
+  resume <site>
+  status <site> [-n 10]           current state and the latest checks
+  check <site>                    check the site now and show the result
+
+Contexts:
+  context list
+  context use <name>
+  context set <name> [-url <url>] [-api-key <key>] [-token <token>] [-org <id>]
+  context delete <name>
+
+<site> is a site ID or its external ID.
+
+Flags:
+`
+
+// sitemonctl is a command-line client for the CRUD API. Where it connects
+// comes from a context in the contexts file, SITEMON_API_URL and
+// SITEMON_API_KEY, and the flags, the latter winning.
+func main() {
+	fs := flag.NewFlagSet("sitemonctl", flag.ExitOnError)
+	contextName := fs.String("context", "", "context to use instead of the current one")
+	output := fs.String("o", outputTable, "output format: table, json or yaml")
+	api := fs.String("api", "", "CRUD API URL")
+	apiKey := fs.String("api-key", "", "API key")
+	org := fs.String("org", "", "organization ID, for platform-wide keys")
+	timeout := fs.Duration("timeout", 30*time.Second, "request timeout")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usageText)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	args := fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if !slices.Contains([]string{outputTable, outputJSON, outputYAML}, *output) {
+		fail("Invalid -o:", fmt.Errorf("expected table, json or yaml, got %q", *output))
+	}
+
+	contexts, err := loadContexts()
+	if err != nil {
+		fail("Failed to load contexts:", err)
+	}
+	if args[0] == "context" {
+		if err := runContext(contexts, args[1:]); err != nil {
+			fail("context:", err)
+		}
+		return
+	}
+
+	cur, err := contexts.get(*contextName)
+	if err != nil {
+		fail("Invalid context:", err)
+	}
+	cfg := client.Config{URL: "http://localhost:8080", APIKey: cur.APIKey, Token: cur.Token, OrgID: cur.Org, Timeout: *timeout}
+	for _, v := range []string{cur.URL, os.Getenv("SITEMON_API_URL"), *api} {
+		if v != "" {
+			cfg.URL = v
+		}
+	}
+	for _, v := range []string{os.Getenv("SITEMON_API_KEY"), *apiKey} {
+		if v != "" {
+			cfg.APIKey, cfg.Token = v, ""
+		}
+	}
+	if *org != "" {
+		cfg.OrgID = *org
+	}
+
+	cmd := &command{c: client.New(cfg), out: printer{w: os.Stdout, format: *output}}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := cmd.run(ctx, args[0], args[1:]); err != nil {
+		var usage usageError
+		if errors.As(err, &usage) {
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, "Run sitemonctl -h for usage.")
+			os.Exit(2)
+		}
+		fail(args[0]+":", err)
+	}
+}
+
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+type command struct {
+	c   *client.Client
+	out printer
+}
+
+func (cmd *command) run(ctx context.Context, name string, args []string) error {
+	switch name {
+	case "list":
+		return cmd.list(ctx, args)
+	case "get", "delete", "pause", "resume", "check":
+		if len(args) != 1 {
+			return usageError("usage: sitemonctl " + name + " <site>")
+		}
+		id, err := cmd.resolve(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "get":
+			s, err := cmd.c.GetSite(ctx, id)
+			if err != nil {
+				return err
+			}
+			return cmd.out.site(s)
+		case "check":
+			res, err := cmd.c.CheckSite(ctx, id)
+			if err != nil {
+				return err
+			}
+			return cmd.out.result(res)
+		case "delete":
+			if err := cmd.c.DeleteSite(ctx, id); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "Deleted", id)
+			return nil
+		default:
+			s, err := cmd.c.SetSiteActive(ctx, id, name == "resume")
+			if err != nil {
+				return err
+			}
+			return cmd.out.site(s)
+		}
+	case "add":
+		return cmd.add(ctx, args)
+	case "update":
+		return cmd.update(ctx, args)
+	case "status":
+		return cmd.status(ctx, args)
+	default:
+		return usageError(fmt.Sprintf("unknown command %q", name))
+	}
+}
+
+func (cmd *command) list(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	active := fs.String("active", "", "only active (true) or paused (false) sites")
+	var tags, labels multiFlag
+	fs.Var(&tags, "tag", "only sites with this tag, repeatable")
+	fs.Var(&labels, "label", "only sites with this label (k=v) or label key (k), repeatable")
+	urlContains := fs.String("url-contains", "", "only sites whose URL contains this")
+	fs.Parse(args)
+
+	q := url.Values{}
+	if *active != "" {
+		q.Set("active", *active)
+	}
+	if *urlContains != "" {
+		q.Set("url_contains", *urlContains)
+	}
+	q["tag"], q["label"] = tags, labels
+
+	sites, err := cmd.c.ListSites(ctx, q)
+	if err != nil {
+		return err
+	}
+	return cmd.out.sites(sites)
+}
+
+// siteFlags are the site fields add and update take.
+type siteFlags struct {
+	fs          *flag.FlagSet
+	url         *string
+	externalID  *string
+	name        *string
+	description *string
+	owner       *string
+	runbookURL  *string
+	team        *string
+	tags        *string
+	labels      multiFlag
+	paused      *bool
+}
+
+func newSiteFlags(name string) *siteFlags {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	f := &siteFlags{
+		fs:          fs,
+		url:         fs.String("url", "", "URL to check"),
+		externalID:  fs.String("external-id", "", "stable key of the site, e.g. from monitors-as-code"),
+		name:        fs.String("name", "", "display name"),
+		description: fs.String("description", "", "description"),
+		owner:       fs.String("owner", "", "owner, e.g. a team email"),
+		runbookURL:  fs.String("runbook-url", "", "runbook linked from alerts"),
+		team:        fs.String("team", "", "team, for escalation and routing"),
+		tags:        fs.String("tags", "", "comma-separated tags, replacing the current ones"),
+		paused:      fs.Bool("paused", false, "don't check the site until it is resumed"),
+	}
+	fs.Var(&f.labels, "label", "label k=v, repeatable; on update k= removes the label")
+	return f
+}
+
+func (cmd *command) add(ctx context.Context, args []string) error {
+	f := newSiteFlags("add")
+	f.fs.Parse(args)
+	if *f.url == "" || f.fs.NArg() > 0 {
+		return usageError("usage: sitemonctl add -url <url> [site flags]")
+	}
+
+	s := client.Site{
+		URL: *f.url, ExternalID: *f.externalID, Name: *f.name, Description: *f.description, Owner: *f.owner,
+		RunbookURL: *f.runbookURL, Team: *f.team, Tags: splitTags(*f.tags), Active: !*f.paused,
+	}
+	for _, l := range f.labels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok {
+			return usageError(fmt.Sprintf("label %q must be key=value", l))
+		}
+		if s.Labels == nil {
+			s.Labels = map[string]string{}
+		}
+		s.Labels[k] = v
+	}
+	created, err := cmd.c.CreateSite(ctx, s)
+	if err != nil {
+		return err
+	}
+	return cmd.out.site(created)
+}
+
+// update sends the flags that were given as a merge patch, so fields that
+// weren't mentioned keep their values.
+func (cmd *command) update(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return usageError("usage: sitemonctl update <site> [site flags]")
+	}
+	f := newSiteFlags("update")
+	f.fs.Parse(args[1:])
+
+	for _, l := range f.labels {
+		if !strings.Contains(l, "=") {
+			return usageError(fmt.Sprintf("label %q must be key=value, or key= to remove it", l))
+		}
+	}
+
+	patch := map[string]any{}
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "tags":
+			patch["tags"] = splitTags(*f.tags)
+		case "paused":
+			patch["active"] = !*f.paused
+		case "label":
+			labels := map[string]any{}
+			for _, l := range f.labels {
+				k, v, _ := strings.Cut(l, "=")
+				if v == "" {
+					labels[k] = nil
+				} else {
+					labels[k] = v
+				}
+			}
+			patch["labels"] = labels
+		default:
+			patch[strings.ReplaceAll(fl.Name, "-", "_")] = fl.Value.String()
+		}
+	})
+	if len(patch) == 0 {
+		return usageError("nothing to update, pass at least one site flag")
+	}
+
+	id, err := cmd.resolve(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	updated, err := cmd.c.PatchSite(ctx, id, patch, 0)
+	if err != nil {
+		return err
+	}
+	return cmd.out.site(updated)
+}
+
+func (cmd *command) status(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return usageError("usage: sitemonctl status <site> [-n 10]")
+	}
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	n := fs.Int("n", 10, "number of recent checks to show")
+	fs.Parse(args[1:])
+
+	id, err := cmd.resolve(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	st, err := cmd.c.GetSiteStatus(ctx, id)
+	if err != nil {
+		return err
+	}
+	var results []client.CheckResult
+	if *n > 0 {
+		if results, err = cmd.c.GetSiteResults(ctx, id, *n); err != nil {
+			return err
+		}
+	}
+	return cmd.out.status(st, results)
+}
+
+// resolve turns a site argument into a site ID: IDs are taken as they are,
+// anything else is looked up as an external ID.
+func (cmd *command) resolve(ctx context.Context, ref string) (string, error) {
+	if _, err := uuid.Parse(ref); err == nil {
+		return ref, nil
+	}
+	sites, err := cmd.c.ListSites(ctx, url.Values{"external_id": {ref}})
+	if err != nil {
+		return "", err
+	}
+	if len(sites) == 0 {
+		return "", fmt.Errorf("no site with ID or external ID %q", ref)
+	}
+	return sites[0].ID, nil
+}
+
+func runContext(f *contextsFile, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: sitemonctl context list|use|set|delete")
+	}
+	switch args[0] {
+	case "list":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "CURRENT\tNAME\tURL\tORG")
+		for _, name := range slices.Sorted(maps.Keys(f.Contexts)) {
+			c := f.Contexts[name]
+			current := ""
+			if name == f.Current {
+				current = "*"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, name, c.URL, dash(c.Org))
+		}
+		return tw.Flush()
+	case "use":
+		if len(args) != 2 {
+			return errors.New("usage: sitemonctl context use <name>")
+		}
+		if _, ok := f.Contexts[args[1]]; !ok {
+			return fmt.Errorf("no context %q in %s", args[1], f.path)
+		}
+		f.Current = args[1]
+	case "set":
+		if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+			return errors.New("usage: sitemonctl context set <name> [-url <url>] [-api-key <key>] [-token <token>] [-org <id>]")
+		}
+		name := args[1]
+		c, ok := f.Contexts[name]
+		if !ok {
+			c = &apiContext{}
+			f.Contexts[name] = c
+		}
+		fs := flag.NewFlagSet("context set", flag.ExitOnError)
+		fs.StringVar(&c.URL, "url", c.URL, "CRUD API URL")
+		fs.StringVar(&c.APIKey, "api-key", c.APIKey, "API key")
+		fs.StringVar(&c.Token, "token", c.Token, "bearer token, instead of an API key")
+		fs.StringVar(&c.Org, "org", c.Org, "organization ID, for platform-wide keys")
+		fs.Parse(args[2:])
+		if c.URL == "" {
+			return errors.New("-url is required for a new context")
+		}
+		if f.Current == "" {
+			f.Current = name
+		}
+	case "delete":
+		if len(args) != 2 {
+			return errors.New("usage: sitemonctl context delete <name>")
+		}
+		if _, ok := f.Contexts[args[1]]; !ok {
+			return fmt.Errorf("no context %q in %s", args[1], f.path)
+		}
+		delete(f.Contexts, args[1])
+		if f.Current == args[1] {
+			f.Current = ""
+		}
+	default:
+		return fmt.Errorf("unknown context command %q", args[0])
+	}
+	return f.save()
+}
+
+type multiFlag []string
+
+func (m *multiFlag) String() string { return strings.Join(*m, ",") }
+
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
+
+func splitTags(s string) []string {
+	tags := []string{}
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func fail(msg string, err error) {
+	fmt.Fprintln(os.Stderr, msg, err)
+	os.Exit(1)
+}
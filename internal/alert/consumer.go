@@ -3,18 +3,32 @@ package alert
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"site-monitor/internal/config"
-	"site-monitor/internal/telegram"
+	"site-monitor/internal/routing"
+	"site-monitor/internal/silence"
+	"site-monitor/internal/storage"
 	"site-monitor/pkg/logger"
+	"site-monitor/pkg/metrics"
+	"site-monitor/pkg/tracing"
 )
 
-func NewAlertConsumer(cfg config.AlertConfig, log *logger.Logger, tg *telegram.Client) *AlertConsumer {
+var tracer = otel.Tracer("site-monitor/internal/alert")
+
+func NewAlertConsumer(cfg config.AlertConfig, log *logger.Logger, store storage.Storage, escalator *Escalator, notifiers ...Notifier) *AlertConsumer {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:     cfg.Kafka.Brokers,
 		Topic:       cfg.Kafka.Topic,
@@ -30,15 +44,38 @@ func NewAlertConsumer(cfg config.AlertConfig, log *logger.Logger, tg *telegram.C
 		DB:       cfg.Redis.DB,
 	})
 
-	return &AlertConsumer{
-		brokers:  cfg.Kafka.Brokers,
-		topic:    cfg.Kafka.Topic,
-		groupID:  cfg.Kafka.GroupID,
-		reader:   reader,
-		log:      log,
-		telegram: tg,
-		redis:    rdb,
+	a := &AlertConsumer{
+		brokers:   cfg.Kafka.Brokers,
+		topic:     cfg.Kafka.Topic,
+		groupID:   cfg.Kafka.GroupID,
+		reader:    reader,
+		log:       log,
+		redis:     rdb,
+		storage:   store,
+		escalator: escalator,
+	}
+	a.settings.Store(&consumerSettings{cfg: cfg, notifiers: notifiers})
+	return a
+}
+
+func (a *AlertConsumer) UpdateConfig(cfg config.AlertConfig, notifiers ...Notifier) {
+	old := a.settings.Load().cfg
+
+	restartRequired := map[string]bool{
+		"server":   !reflect.DeepEqual(old.Server, cfg.Server),
+		"kafka":    !reflect.DeepEqual(old.Kafka, cfg.Kafka),
+		"redis":    !reflect.DeepEqual(old.Redis, cfg.Redis),
+		"postgres": !reflect.DeepEqual(old.Postgres, cfg.Postgres),
+		"tracing":  !reflect.DeepEqual(old.Tracing, cfg.Tracing),
+	}
+	for section, changed := range restartRequired {
+		if changed {
+			a.log.Sugar.Warnw("Config section changed, restart the alert service to apply it", "section", section)
+		}
 	}
+	cfg.Server, cfg.Kafka, cfg.Redis, cfg.Postgres, cfg.Tracing = old.Server, old.Kafka, old.Redis, old.Postgres, old.Tracing
+
+	a.settings.Store(&consumerSettings{cfg: cfg, notifiers: notifiers})
 }
 
 func (a *AlertConsumer) Consume(ctx context.Context) {
@@ -53,37 +90,219 @@ func (a *AlertConsumer) Consume(ctx context.Context) {
 			a.log.Sugar.Errorw("Error reading message", "error", err)
 			continue
 		}
+		a.handleMessage(ctx, m)
+	}
+}
 
-		var alert AlertMessage
-		if err := json.Unmarshal(m.Value, &alert); err != nil {
-			a.log.Sugar.Errorw("Failed to parse alert JSON", "error", err, "raw", string(m.Value))
-			continue
+func (a *AlertConsumer) handleMessage(ctx context.Context, m kafka.Message) {
+	ctx, span := tracer.Start(tracing.ExtractKafka(ctx, m), "AlertConsumer.Consume",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", m.Topic)),
+	)
+	defer span.End()
+
+	metrics.AlertMessagesConsumed.Inc()
+	metrics.AlertConsumerLag.Set(float64(a.reader.Stats().Lag))
+
+	if messageType(m) == messageTypeMaintenanceEnd {
+		a.handleMaintenanceSummary(ctx, m.Value)
+		return
+	}
+
+	var alert AlertMessage
+	if err := json.Unmarshal(m.Value, &alert); err != nil {
+		metrics.AlertParseErrors.Inc()
+		a.log.Ctx(ctx).Errorw("Failed to parse alert JSON", "error", err, "raw", string(m.Value))
+		return
+	}
+	alert.OrgID = orgOf(alert.OrgID)
+
+	isUp := alert.Status == 200
+	a.recordResult(ctx, alert, isUp)
+
+	send, err := a.shouldSendAlert(alert.OrgID, alert.URL, isUp)
+	if err != nil {
+		a.log.Ctx(ctx).Errorw("Redis error", "error", err)
+		return
+	}
+
+	if !send {
+		a.log.Ctx(ctx).Infow("No alert sent, status unchanged", "url", alert.URL, "status", alert.Status)
+		return
+	}
+
+	incidentID := a.trackIncident(ctx, alert, isUp)
+
+	if alert.Maintenance {
+		a.log.Ctx(ctx).Infow("Alert suppressed by maintenance window",
+			"url", alert.URL, "status", alert.Status, "window", alert.MaintenanceWindow)
+		a.recordSuppressed(ctx, alert, storage.SuppressedEvent{
+			Reason:              suppressedByMaintenance,
+			MaintenanceWindowID: alert.MaintenanceWindow,
+		})
+		return
+	}
+
+	if s := a.matchSilence(ctx, alert); s != nil {
+		a.log.Ctx(ctx).Infow("Alert suppressed by silence", "url", alert.URL, "status", alert.Status, "silence", s.ID)
+		a.recordSuppressed(ctx, alert, storage.SuppressedEvent{Reason: suppressedBySilence, SilenceID: s.ID})
+		return
+	}
+
+	severity := routing.SeverityCritical
+	if isUp {
+		severity = routing.SeverityInfo
+	}
+
+	prettyMsg, _ := formatAlert(m.Value)
+	a.notifyRouted(ctx, alert.OrgID, routing.Alert{Tags: alert.Tags, Team: alert.Team, Severity: severity, Time: time.Now()}, prettyMsg)
+
+	if incidentID != "" && a.escalator != nil {
+		a.escalator.Start(ctx, incidentID, alert)
+	}
+}
+
+func (a *AlertConsumer) trackIncident(ctx context.Context, alert AlertMessage, isUp bool) string {
+	if isUp {
+		if err := a.storage.ResolveIncident(ctx, alert.OrgID, alert.URL); err != nil {
+			a.log.Ctx(ctx).Errorw("Failed to resolve incident", "url", alert.URL, "error", err)
 		}
+		return ""
+	}
 
-		isUp := alert.Status == 200
-		send, err := a.shouldSendAlert(alert.URL, isUp)
-		if err != nil {
-			a.log.Sugar.Errorw("Redis error", "error", err)
-			continue
+	id, err := a.storage.OpenIncident(ctx, alert.OrgID, storage.Incident{SiteID: alert.SiteID, URL: alert.URL})
+	if err != nil {
+		a.log.Ctx(ctx).Errorw("Failed to open incident", "url", alert.URL, "error", err)
+		return ""
+	}
+	a.log.Ctx(ctx).Infow("Incident opened", "id", id, "url", alert.URL)
+	return id
+}
+
+// recordResult stores the check for the site's status and history in the
+// CRUD API. A failure here doesn't hold up the alert.
+func (a *AlertConsumer) recordResult(ctx context.Context, alert AlertMessage, isUp bool) {
+	checkedAt, err := time.Parse(time.RFC3339Nano, alert.Timestamp)
+	if err != nil {
+		checkedAt = time.Now()
+	}
+	err = a.storage.AddCheckResult(ctx, alert.OrgID, storage.CheckResult{
+		SiteID: alert.SiteID, URL: alert.URL, Status: alert.Status, Up: isUp, ResponseTimeMs: alert.ResponseTimeMs,
+		Error: alert.Error, Maintenance: alert.Maintenance, CheckedAt: checkedAt,
+	})
+	if err != nil {
+		a.log.Ctx(ctx).Errorw("Failed to record check result", "url", alert.URL, "error", err)
+	}
+}
+
+// PruneResults deletes check results older than results.retention_hours
+// once an hour until ctx is done. Every replica prunes; the deletes are
+// idempotent.
+func (a *AlertConsumer) PruneResults(ctx context.Context) {
+	ticker := time.NewTicker(resultsPruneInterval)
+	defer ticker.Stop()
+	for {
+		retention := time.Duration(a.settings.Load().cfg.Results.RetentionHours) * time.Hour
+		n, err := a.storage.DeleteCheckResultsBefore(ctx, time.Now().Add(-retention))
+		if err != nil && ctx.Err() == nil {
+			a.log.Sugar.Errorw("Failed to prune check results", "error", err)
+		} else if n > 0 {
+			a.log.Sugar.Infow("Pruned check results", "deleted", n, "retention", retention)
 		}
 
-		if !send {
-			a.log.Sugar.Infow("No alert sent, status unchanged", "url", alert.URL, "status", alert.Status)
-			continue
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
+	}
+}
+
+func (a *AlertConsumer) matchSilence(ctx context.Context, alert AlertMessage) *storage.Silence {
+	silences, err := a.storage.GetSilences(ctx, alert.OrgID, true)
+	if err != nil {
+		a.log.Ctx(ctx).Errorw("Failed to get silences, notifying anyway", "error", err)
+		return nil
+	}
+	for _, s := range silences {
+		if silence.Matches(s, alert.SiteID, alert.URL, alert.Tags) {
+			return &s
+		}
+	}
+	return nil
+}
+
+func (a *AlertConsumer) recordSuppressed(ctx context.Context, alert AlertMessage, e storage.SuppressedEvent) {
+	e.SiteID = alert.SiteID
+	e.URL = alert.URL
+	e.Status = alert.Status
+	if err := a.storage.AddSuppressedEvent(ctx, alert.OrgID, e); err != nil {
+		a.log.Ctx(ctx).Errorw("Failed to record suppressed event", "url", alert.URL, "error", err)
+	}
+}
+
+func (a *AlertConsumer) handleMaintenanceSummary(ctx context.Context, raw []byte) {
+	var summary MaintenanceSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		metrics.AlertParseErrors.Inc()
+		a.log.Ctx(ctx).Errorw("Failed to parse maintenance summary JSON", "error", err, "raw", string(raw))
+		return
+	}
+	summary.OrgID = orgOf(summary.OrgID)
 
-		prettyMsg, _ := formatAlert(m.Value)
-		if err := a.telegram.SendMessage(prettyMsg); err != nil {
-			a.log.Sugar.Errorw("Failed to send Telegram alert", "error", err)
-		} else {
-			a.log.Sugar.Infow("Send alert to Telegram", "message", prettyMsg)
+	a.log.Ctx(ctx).Infow("Maintenance window ended with failing sites",
+		"window", summary.WindowID, "failing", len(summary.Failing))
+	a.notifyRouted(ctx, summary.OrgID, routing.Alert{Severity: routing.SeverityWarning, Time: time.Now()}, formatMaintenanceSummary(summary))
+}
+
+func messageType(m kafka.Message) string {
+	for _, h := range m.Headers {
+		if h.Key == messageTypeHeader {
+			return string(h.Value)
 		}
 	}
+	return ""
+}
+
+func (a *AlertConsumer) notify(ctx context.Context, message string, notifiers []Notifier) {
+	for _, n := range notifiers {
+		a.sendVia(ctx, n, message)
+	}
+}
+
+func (a *AlertConsumer) sendVia(ctx context.Context, n Notifier, message string) {
+	ctx, span := tracer.Start(ctx, "notifier."+n.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("notifier", n.Name())),
+	)
+	defer span.End()
+
+	start := time.Now()
+	err := n.SendMessage(message)
+	metrics.AlertNotifierDuration.WithLabelValues(n.Name()).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		tracing.RecordError(span, err)
+		metrics.AlertsFailed.WithLabelValues(n.Name()).Inc()
+		a.log.Ctx(ctx).Errorw("Failed to send alert", "notifier", n.Name(), "error", err)
+		return
+	}
+	metrics.AlertsSent.WithLabelValues(n.Name()).Inc()
+	a.log.Ctx(ctx).Infow("Send alert", "notifier", n.Name(), "message", message)
 }
 
-func (a *AlertConsumer) shouldSendAlert(url string, isUp bool) (bool, error) {
-	key := "site_status:" + url
+func (a *AlertConsumer) shouldSendAlert(orgID, url string, isUp bool) (bool, error) {
+	key := "site_status:" + orgID + ":" + url
 	val, err := a.redis.Get(key).Result()
+	if err == redis.Nil {
+		// Status stored before keys were per organization, so an upgrade
+		// doesn't re-alert every site.
+		val, err = a.redis.Get("site_status:" + url).Result()
+	}
+	if err != nil && err != redis.Nil {
+		metrics.AlertRedisErrors.Inc()
+		return false, err
+	}
 
 	var state SiteState
 	if err == nil {
@@ -102,7 +321,10 @@ func (a *AlertConsumer) shouldSendAlert(url string, isUp bool) (bool, error) {
 	}
 
 	b, _ := json.Marshal(state)
-	a.redis.Set(key, b, 0)
+	if err := a.redis.Set(key, b, 0).Err(); err != nil {
+		metrics.AlertRedisErrors.Inc()
+		a.log.Sugar.Errorw("Failed to save site state", "url", url, "error", err)
+	}
 
 	return send, nil
 }
@@ -128,10 +350,74 @@ func formatAlert(raw []byte) (string, error) {
 	if alert.Error != "" {
 		msg += fmt.Sprintf("\n⚠️ *Error*: `%s`", alert.Error)
 	}
+	msg += formatSiteMetadata(alert)
 
 	return msg, nil
 }
 
+// formatSiteMetadata renders what the site's owners recorded about it, in a
+// stable order so repeated alerts look the same.
+func formatSiteMetadata(alert AlertMessage) string {
+	var b strings.Builder
+	if alert.Name != "" {
+		fmt.Fprintf(&b, "\n🏷 *Site*: %s", alert.Name)
+	}
+	if alert.Owner != "" {
+		fmt.Fprintf(&b, "\n👤 *Owner*: %s", alert.Owner)
+	}
+	if alert.RunbookURL != "" {
+		fmt.Fprintf(&b, "\n📖 *Runbook*: %s", alert.RunbookURL)
+	}
+	if len(alert.Labels) > 0 {
+		pairs := make([]string, 0, len(alert.Labels))
+		for _, k := range slices.Sorted(maps.Keys(alert.Labels)) {
+			pairs = append(pairs, k+"="+alert.Labels[k])
+		}
+		fmt.Fprintf(&b, "\n🔖 *Labels*: `%s`", strings.Join(pairs, ", "))
+	}
+	return b.String()
+}
+
+func formatMaintenanceSummary(summary MaintenanceSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🛠 *Maintenance finished: %s*\n\n%d site(s) are still unavailable:\n", summary.WindowName, len(summary.Failing))
+	for _, f := range summary.Failing {
+		fmt.Fprintf(&b, "\n🌐 %s — status %d", f.URL, f.Status)
+		if f.Error != "" {
+			fmt.Fprintf(&b, " (`%s`)", f.Error)
+		}
+	}
+	return b.String()
+}
+
+func (a *AlertConsumer) PingRedis(context.Context) error {
+	if err := a.redis.Ping().Err(); err != nil {
+		metrics.AlertRedisErrors.Inc()
+		return err
+	}
+	return nil
+}
+
+// PingNotifiers checks the default notifiers that can report reachability.
+// Routed channels are resolved per alert and aren't checked here.
+func (a *AlertConsumer) PingNotifiers(ctx context.Context) error {
+	var errs []error
+	for _, n := range a.settings.Load().notifiers {
+		p, ok := n.(Pinger)
+		if !ok {
+			continue
+		}
+		if err := p.Ping(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *AlertConsumer) Brokers() []string {
+	return a.brokers
+}
+
 func (a *AlertConsumer) Close() error {
 	return a.reader.Close()
 }
@@ -0,0 +1,212 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"site-monitor/internal/oncall"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/logger"
+	"site-monitor/pkg/metrics"
+)
+
+type Pager interface {
+	Name() string
+	SendMessageTo(chatID, message string) error
+}
+
+type Escalator struct {
+	storage  storage.Storage
+	log      *logger.Logger
+	settings atomic.Pointer[escalatorSettings]
+	reloadCh chan struct{}
+}
+
+type escalatorSettings struct {
+	pager    Pager
+	interval time.Duration
+}
+
+func NewEscalator(store storage.Storage, pager Pager, log *logger.Logger, interval time.Duration) *Escalator {
+	e := &Escalator{
+		storage:  store,
+		log:      log,
+		reloadCh: make(chan struct{}, 1),
+	}
+	e.settings.Store(&escalatorSettings{pager: pager, interval: interval})
+	return e
+}
+
+func (e *Escalator) Update(pager Pager, interval time.Duration) {
+	e.settings.Store(&escalatorSettings{pager: pager, interval: interval})
+	select {
+	case e.reloadCh <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Escalator) Run(ctx context.Context) {
+	interval := e.settings.Load().interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	e.log.Sugar.Infow("Escalation loop started", "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.log.Sugar.Infow("Escalation loop stopped")
+			return
+		case <-e.reloadCh:
+			if next := e.settings.Load().interval; next != interval {
+				interval = next
+				ticker.Reset(interval)
+				e.log.Sugar.Infow("Escalation interval changed", "interval", interval)
+			}
+		case <-ticker.C:
+			e.processDue(ctx)
+		}
+	}
+}
+
+func (e *Escalator) processDue(ctx context.Context) {
+	for {
+		processed, err := e.storage.ProcessDueEscalation(ctx, func(inc storage.Incident) (*time.Time, error) {
+			return e.escalate(ctx, inc)
+		})
+		if err != nil {
+			e.log.Ctx(ctx).Errorw("Failed to process escalation", "error", err)
+		}
+		if !processed {
+			return
+		}
+	}
+}
+
+func (e *Escalator) Start(ctx context.Context, incidentID string, alert AlertMessage) {
+	if alert.SiteID == "" {
+		return
+	}
+
+	site, err := e.storage.GetSiteByID(ctx, alert.OrgID, alert.SiteID)
+	if err != nil {
+		e.log.Ctx(ctx).Errorw("Failed to get site for escalation", "site_id", alert.SiteID, "error", err)
+		return
+	}
+	if site == nil || site.Team == "" {
+		return
+	}
+
+	policy, err := e.storage.GetEscalationPolicyByTeam(ctx, alert.OrgID, site.Team)
+	if err != nil {
+		e.log.Ctx(ctx).Errorw("Failed to get escalation policy", "team", site.Team, "error", err)
+		return
+	}
+	if policy == nil || len(policy.Levels) == 0 {
+		return
+	}
+
+	started, err := e.storage.StartEscalation(ctx, alert.OrgID, incidentID, policy.ID, nextEscalation(*policy, 0))
+	if err != nil {
+		e.log.Ctx(ctx).Errorw("Failed to start escalation", "incident", incidentID, "error", err)
+		return
+	}
+	if !started {
+		return
+	}
+
+	msg := fmt.Sprintf("📟 *You are on call for %s*\n\n🌐 *URL*: %s\n📊 *Status*: %d\n🆔 *Incident*: `%s`",
+		site.Team, alert.URL, alert.Status, incidentID)
+	msg += formatSiteMetadata(alert)
+	if err := e.notifyLevel(ctx, alert.OrgID, policy.Levels[0], msg); err != nil {
+		e.log.Ctx(ctx).Errorw("Failed to notify on-call", "incident", incidentID, "team", site.Team, "error", err)
+	}
+}
+
+func (e *Escalator) escalate(ctx context.Context, inc storage.Incident) (*time.Time, error) {
+	policy, err := e.storage.GetEscalationPolicyByID(ctx, inc.OrgID, inc.EscalationPolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	level := inc.EscalationLevel + 1
+	if policy == nil || level >= len(policy.Levels) {
+		return nil, nil
+	}
+
+	msg := fmt.Sprintf("🔺 *Escalation, level %d*\n\n🌐 *URL*: %s\n🕒 *Down since*: %s\n🆔 *Incident*: `%s` is not acknowledged",
+		level, inc.URL, inc.OpenedAt.Format(time.RFC3339), inc.ID)
+	if err := e.notifyLevel(ctx, inc.OrgID, policy.Levels[level], msg); err != nil {
+		return nil, err
+	}
+
+	e.log.Ctx(ctx).Infow("Incident escalated", "incident", inc.ID, "level", level, "team", policy.Team)
+	return nextEscalation(*policy, level), nil
+}
+
+func (e *Escalator) notifyLevel(ctx context.Context, orgID string, level storage.EscalationLevel, message string) error {
+	chatIDs := append([]string{}, level.ChatIDs...)
+
+	if level.ScheduleID != "" {
+		chatID, err := e.onCallChatID(ctx, orgID, level.ScheduleID)
+		if err != nil {
+			e.log.Ctx(ctx).Errorw("Failed to resolve on-call participant", "schedule_id", level.ScheduleID, "error", err)
+		} else if chatID != "" {
+			chatIDs = append(chatIDs, chatID)
+		}
+	}
+
+	if len(chatIDs) == 0 {
+		return errors.New("no recipients for escalation level")
+	}
+
+	pager := e.settings.Load().pager
+
+	var errs []error
+	for _, chatID := range chatIDs {
+		start := time.Now()
+		err := pager.SendMessageTo(chatID, message)
+		metrics.AlertNotifierDuration.WithLabelValues(pager.Name()).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			metrics.AlertsFailed.WithLabelValues(pager.Name()).Inc()
+			errs = append(errs, err)
+			continue
+		}
+		metrics.AlertsSent.WithLabelValues(pager.Name()).Inc()
+	}
+
+	if len(errs) == len(chatIDs) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+func (e *Escalator) onCallChatID(ctx context.Context, orgID, scheduleID string) (string, error) {
+	schedule, err := e.storage.GetOnCallScheduleByID(ctx, orgID, scheduleID)
+	if err != nil || schedule == nil {
+		return "", err
+	}
+
+	overrides, err := e.storage.GetOnCallOverrides(ctx, orgID, scheduleID)
+	if err != nil {
+		return "", err
+	}
+
+	p, err := oncall.WhoIsOnCall(*schedule, overrides, time.Now())
+	if err != nil || p == nil {
+		return "", err
+	}
+	return p.ChatID, nil
+}
+
+func nextEscalation(policy storage.EscalationPolicy, level int) *time.Time {
+	if level+1 >= len(policy.Levels) {
+		return nil
+	}
+	next := time.Now().Add(time.Duration(policy.Levels[level].EscalateAfterMinutes) * time.Minute)
+	return &next
+}
@@ -0,0 +1,92 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+
+	"site-monitor/internal/routing"
+	"site-monitor/internal/slack"
+	"site-monitor/internal/storage"
+	"site-monitor/internal/telegram"
+	"site-monitor/internal/webhook"
+)
+
+// notifyRouted only ever uses routes and channels of orgID. Alerts that match
+// nothing fall back to the default notifiers for the default organization and
+// are dropped for everyone else, so one tenant's outage never lands in a chat
+// that belongs to somebody else.
+func (a *AlertConsumer) notifyRouted(ctx context.Context, orgID string, alert routing.Alert, message string) {
+	notifiers, err := a.routeNotifiers(ctx, orgID, alert)
+	if err != nil {
+		a.log.Ctx(ctx).Errorw("Failed to resolve notification routes", "org_id", orgID, "error", err)
+	}
+	if len(notifiers) == 0 {
+		if !routing.FallbackAllowed(orgID) {
+			a.log.Ctx(ctx).Warnw("No route matched the alert, nothing sent", "org_id", orgID)
+			return
+		}
+		notifiers = a.settings.Load().notifiers
+	}
+	a.notify(ctx, message, notifiers)
+}
+
+func (a *AlertConsumer) routeNotifiers(ctx context.Context, orgID string, alert routing.Alert) ([]Notifier, error) {
+	routes, err := a.storage.GetRoutes(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := routing.Resolve(routes, alert)
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	channels, err := a.storage.GetChannels(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]storage.NotificationChannel, len(channels))
+	for _, c := range channels {
+		byID[c.ID] = c
+	}
+
+	var notifiers []Notifier
+	seen := make(map[string]bool)
+	for _, r := range matched {
+		for _, id := range r.ChannelIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			ch, ok := byID[id]
+			if !ok {
+				a.log.Ctx(ctx).Warnw("Route references unknown channel", "route", r.ID, "channel", id)
+				continue
+			}
+			n, err := a.channelNotifier(ch)
+			if err != nil {
+				a.log.Ctx(ctx).Warnw("Failed to build notifier for channel", "channel", id, "error", err)
+				continue
+			}
+			notifiers = append(notifiers, n)
+		}
+	}
+	return notifiers, nil
+}
+
+func (a *AlertConsumer) channelNotifier(ch storage.NotificationChannel) (Notifier, error) {
+	switch ch.Type {
+	case routing.ChannelTelegram:
+		token := ch.Settings["bot_token"]
+		if token == "" {
+			token = a.settings.Load().cfg.Telegram.BotToken
+		}
+		return telegram.NewClient(token, ch.Settings["chat_id"]), nil
+	case routing.ChannelSlack:
+		return slack.NewClient(ch.Settings["webhook_url"]), nil
+	case routing.ChannelWebhook:
+		return webhook.NewClient(ch.Settings["url"]), nil
+	}
+	return nil, fmt.Errorf("unknown channel type %q", ch.Type)
+}
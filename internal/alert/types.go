@@ -1,30 +1,90 @@
 package alert
 
 import (
-	"site-monitor/internal/telegram"
+	"context"
+	"site-monitor/internal/config"
+	"site-monitor/internal/storage"
 	"site-monitor/pkg/logger"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis"
 	"github.com/segmentio/kafka-go"
 )
 
+const (
+	suppressedByMaintenance = "maintenance"
+	suppressedBySilence     = "silence"
+)
+
+const resultsPruneInterval = time.Hour
+
+const (
+	messageTypeHeader         = "type"
+	messageTypeMaintenanceEnd = "maintenance_summary"
+)
+
+// Pinger is implemented by notifiers that can check their reachability
+// without sending a message.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Notifier interface {
+	Name() string
+	SendMessage(message string) error
+}
+
 type AlertConsumer struct {
-	brokers  []string
-	topic    string
-	groupID  string
-	reader   *kafka.Reader
-	log      *logger.Logger
-	telegram *telegram.Client
-	redis    *redis.Client
+	brokers   []string
+	topic     string
+	groupID   string
+	reader    *kafka.Reader
+	log       *logger.Logger
+	redis     *redis.Client
+	storage   storage.Storage
+	escalator *Escalator
+	settings  atomic.Pointer[consumerSettings]
+}
+
+type consumerSettings struct {
+	cfg       config.AlertConfig
+	notifiers []Notifier
 }
 
 type AlertMessage struct {
-	URL            string `json:"url"`
-	Status         int    `json:"status"`
-	ResponseTimeMs int    `json:"response_time_ms"`
-	Error          string `json:"error"`
-	Timestamp      string `json:"timestamp"`
+	SiteID            string            `json:"site_id"`
+	OrgID             string            `json:"org_id"`
+	URL               string            `json:"url"`
+	Tags              []string          `json:"tags,omitempty"`
+	Team              string            `json:"team,omitempty"`
+	Name              string            `json:"name,omitempty"`
+	Owner             string            `json:"owner,omitempty"`
+	RunbookURL        string            `json:"runbook_url,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Status            int               `json:"status"`
+	ResponseTimeMs    int               `json:"response_time_ms"`
+	Error             string            `json:"error"`
+	Timestamp         string            `json:"timestamp"`
+	Maintenance       bool              `json:"maintenance"`
+	MaintenanceWindow string            `json:"maintenance_window,omitempty"`
+}
+
+type MaintenanceSummary struct {
+	WindowID   string         `json:"window_id"`
+	OrgID      string         `json:"org_id"`
+	WindowName string         `json:"window_name"`
+	EndedAt    time.Time      `json:"ended_at"`
+	Failing    []AlertMessage `json:"failing"`
+}
+
+// orgOf maps messages published before organizations existed to the
+// default organization.
+func orgOf(orgID string) string {
+	if orgID == "" {
+		return storage.DefaultOrgID
+	}
+	return orgID
 }
 
 type SiteState struct {
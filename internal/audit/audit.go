@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+
+	"site-monitor/internal/config"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/logger"
+	"site-monitor/pkg/metrics"
+	"site-monitor/pkg/tracing"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+
+	ResourceSite    = "site"
+	ResourceSilence = "silence"
+	ResourceChannel = "channel"
+
+	ResourceMaintenance = "maintenance_window"
+	ResourceRoute       = "route"
+	ResourceSchedule    = "schedule"
+	ResourceOverride    = "override"
+	ResourcePolicy      = "escalation_policy"
+	ResourceIncident    = "incident"
+
+	sinkPostgres = "postgres"
+	sinkKafka    = "kafka"
+)
+
+type Store interface {
+	AddAuditEntry(ctx context.Context, orgID string, e storage.AuditEntry) error
+}
+
+// Event describes a change before it is turned into an audit entry. Before
+// is nil for creations and After is nil for deletions.
+type Event struct {
+	OrgID        string
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	SourceIP     string
+	Before       any
+	After        any
+}
+
+type Recorder struct {
+	store  Store
+	writer *kafka.Writer
+	log    *logger.Logger
+}
+
+func NewRecorder(store Store, cfg config.AuditConfig, log *logger.Logger) *Recorder {
+	r := &Recorder{store: store, log: log}
+	if cfg.StreamEnabled() {
+		r.writer = &kafka.Writer{
+			Addr:     kafka.TCP(cfg.Kafka.Brokers...),
+			Topic:    cfg.Kafka.Topic,
+			Balancer: &kafka.Hash{},
+			// Async keeps API latency independent of Kafka; the table is the
+			// source of truth and the stream is best effort.
+			Async: true,
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					metrics.AuditFailures.WithLabelValues(sinkKafka).Add(float64(len(messages)))
+					log.Sugar.Errorw("Failed to stream audit events", "count", len(messages), "error", err)
+				}
+			},
+		}
+	}
+	return r
+}
+
+// Record stores the event in the audit log and streams it when Kafka is
+// configured. Failures are logged and counted but never fail the change
+// itself, which has already been applied.
+func (r *Recorder) Record(ctx context.Context, ev Event) {
+	entry, err := newEntry(ev)
+	if err != nil {
+		metrics.AuditFailures.WithLabelValues(sinkPostgres).Inc()
+		r.log.Ctx(ctx).Errorw("Failed to build audit entry", "resource", ev.ResourceType, "id", ev.ResourceID, "error", err)
+		return
+	}
+
+	if err := r.store.AddAuditEntry(ctx, ev.OrgID, entry); err != nil {
+		metrics.AuditFailures.WithLabelValues(sinkPostgres).Inc()
+		r.log.Ctx(ctx).Errorw("Failed to write audit entry", "resource", ev.ResourceType, "id", ev.ResourceID, "error", err)
+	}
+
+	if r.writer != nil {
+		r.stream(ctx, entry)
+	}
+}
+
+func (r *Recorder) stream(ctx context.Context, entry storage.AuditEntry) {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		metrics.AuditFailures.WithLabelValues(sinkKafka).Inc()
+		r.log.Ctx(ctx).Errorw("Failed to marshal audit event", "error", err)
+		return
+	}
+
+	// Keyed by resource so all changes of one object stay in order.
+	msg := kafka.Message{Key: []byte(entry.ResourceType + ":" + entry.ResourceID), Value: value}
+	tracing.InjectKafka(ctx, &msg)
+	if err := r.writer.WriteMessages(context.WithoutCancel(ctx), msg); err != nil {
+		metrics.AuditFailures.WithLabelValues(sinkKafka).Inc()
+		r.log.Ctx(ctx).Errorw("Failed to stream audit event", "error", err)
+	}
+}
+
+func (r *Recorder) Close() error {
+	if r.writer == nil {
+		return nil
+	}
+	return r.writer.Close()
+}
+
+func newEntry(ev Event) (storage.AuditEntry, error) {
+	// ID and time are set here rather than by Postgres so the streamed event
+	// matches the stored row.
+	entry := storage.AuditEntry{
+		ID:           uuid.New().String(),
+		CreatedAt:    time.Now().UTC(),
+		OrgID:        ev.OrgID,
+		Actor:        ev.Actor,
+		Action:       ev.Action,
+		ResourceType: ev.ResourceType,
+		ResourceID:   ev.ResourceID,
+		RequestID:    ev.RequestID,
+		SourceIP:     ev.SourceIP,
+	}
+
+	var before, after map[string]any
+	var err error
+	if entry.Before, before, err = toJSON(ev.Before); err != nil {
+		return entry, err
+	}
+	if entry.After, after, err = toJSON(ev.After); err != nil {
+		return entry, err
+	}
+	entry.Changes = Diff(before, after)
+	return entry, nil
+}
+
+func toJSON(v any) (json.RawMessage, map[string]any, error) {
+	if v == nil {
+		return nil, nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, nil, err
+	}
+	return raw, fields, nil
+}
+
+// Diff compares two JSON objects field by field. A field missing on one side
+// shows up with a null value there.
+func Diff(before, after map[string]any) map[string]storage.AuditChange {
+	changes := make(map[string]storage.AuditChange)
+	for k, from := range before {
+		if to, ok := after[k]; !ok || !reflect.DeepEqual(from, to) {
+			changes[k] = storage.AuditChange{From: from, To: after[k]}
+		}
+	}
+	for k, to := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = storage.AuditChange{To: to}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	RoleViewer  = "viewer"
+	RoleEditor  = "editor"
+	RoleAdmin   = "admin"
+	RoleService = "service"
+
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+	MethodNone   = "none"
+
+	// Tells API keys apart from JWTs and helps secret scanners.
+	KeyPrefix = "smk_"
+
+	keyBytes         = 32
+	displayPrefixLen = len(KeyPrefix) + 6
+)
+
+var roleRank = map[string]int{
+	RoleViewer:  1,
+	RoleService: 1,
+	RoleEditor:  2,
+	RoleAdmin:   3,
+}
+
+func ValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+func Allows(have, need string) bool {
+	return roleRank[have] >= roleRank[need] && roleRank[have] > 0
+}
+
+// OrgID is empty for platform-wide callers.
+type Principal struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+	Method  string `json:"method"`
+	OrgID   string `json:"org_id,omitempty"`
+}
+
+func (p Principal) Global() bool {
+	return p.OrgID == ""
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+func GenerateKey() (key, prefix, hash string, err error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("generate API key: %w", err)
+	}
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:displayPrefixLen], HashKey(key), nil
+}
+
+// Keys are 256 random bits, so an unsalted hash is enough.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
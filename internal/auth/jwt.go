@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"site-monitor/internal/config"
+)
+
+// minJWKSRefresh bounds how often an unknown kid can trigger a JWKS download,
+// so random tokens can't be used to hammer the identity provider.
+const minJWKSRefresh = 30 * time.Second
+
+var validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+type JWTVerifier struct {
+	cfg    config.JWTConfig
+	parser *jwt.Parser
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewJWTVerifier loads the JWKS once up front so a misconfigured source fails
+// at startup rather than on the first request.
+func NewJWTVerifier(ctx context.Context, cfg config.JWTConfig) (*JWTVerifier, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods(validMethods), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	v := &JWTVerifier{
+		cfg:    cfg,
+		parser: jwt.NewParser(opts...),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Verify checks the token signature and standard claims and maps the role
+// and organization claims to a principal.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return Principal{}, err
+	}
+
+	role := highestRole(claims[v.cfg.RoleClaim])
+	if role == "" {
+		return Principal{}, fmt.Errorf("token has no valid %q claim", v.cfg.RoleClaim)
+	}
+	// Tokens are always bound to a tenant; platform-wide access is reserved
+	// for API keys issued by an operator.
+	orgID, _ := claims[v.cfg.OrgClaim].(string)
+	if _, err := uuid.Parse(orgID); err != nil {
+		return Principal{}, fmt.Errorf("token has no valid %q claim", v.cfg.OrgClaim)
+	}
+	sub, _ := claims.GetSubject()
+	return Principal{Subject: sub, Role: role, Method: MethodJWT, OrgID: orgID}, nil
+}
+
+func (v *JWTVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	key, ok := v.lookup(kid)
+	stale := time.Since(v.fetchedAt) > time.Duration(v.cfg.RefreshInterval)*time.Second
+	canRefresh := v.cfg.JWKSURL != "" && time.Since(v.attemptedAt) > minJWKSRefresh
+	v.mu.Unlock()
+
+	if canRefresh && (stale || !ok) {
+		// A failed refresh keeps the cached keys, so an identity provider
+		// outage doesn't log everyone out.
+		if err := v.refresh(ctx); err != nil && !ok {
+			return nil, err
+		}
+		v.mu.Lock()
+		key, ok = v.lookup(kid)
+		v.mu.Unlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// lookup finds the key by kid. Tokens without a kid are accepted only when
+// the set holds a single key. The caller must hold v.mu.
+func (v *JWTVerifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, true
+		}
+	}
+	k, ok := v.keys[kid]
+	return k, ok
+}
+
+func (v *JWTVerifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	v.attemptedAt = time.Now()
+	v.mu.Unlock()
+
+	data, err := v.readJWKS(ctx)
+	if err != nil {
+		return fmt.Errorf("load JWKS: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWTVerifier) readJWKS(ctx context.Context) ([]byte, error) {
+	if v.cfg.JWKSFile != "" {
+		return os.ReadFile(v.cfg.JWKSFile)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys found")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64url value: %w", err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// highestRole accepts a single role or a list of roles and returns the
+// strongest known one.
+func highestRole(claim interface{}) string {
+	var roles []string
+	switch c := claim.(type) {
+	case string:
+		roles = []string{c}
+	case []interface{}:
+		for _, r := range c {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+	}
+
+	best := ""
+	for _, r := range roles {
+		if ValidRole(r) && roleRank[r] > roleRank[best] {
+			best = r
+		}
+	}
+	return best
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"site-monitor/internal/config"
+)
+
+const testOrg = "5f0c7a52-3b1e-4c8e-9a51-2d4b6f1e8c3a"
+
+func newTestVerifier(t *testing.T) (*JWTVerifier, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k1","use":"sig","x":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(pub))
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(jwks), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewJWTVerifier(context.Background(), config.JWTConfig{
+		JWKSFile:  path,
+		Issuer:    "https://idp.example",
+		RoleClaim: "role",
+		OrgClaim:  "org_id",
+	})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+	return v, priv
+}
+
+func signToken(t *testing.T, key ed25519.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestJWTVerify(t *testing.T) {
+	v, key := newTestVerifier(t)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":    "alice",
+			"iss":    "https://idp.example",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"role":   RoleEditor,
+			"org_id": testOrg,
+		}
+		for k, val := range overrides {
+			if val == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = val
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantRole string
+		wantErr  string
+	}{
+		{"valid", signToken(t, key, "k1", claims(nil)), RoleEditor, ""},
+		{"no kid with a single key", signToken(t, key, "", claims(nil)), RoleEditor, ""},
+		{"highest of several roles", signToken(t, key, "k1", claims(jwt.MapClaims{"role": []interface{}{"viewer", "admin", "owner"}})), RoleAdmin, ""},
+		{"no org claim", signToken(t, key, "k1", claims(jwt.MapClaims{"org_id": nil})), "", `"org_id" claim`},
+		{"org claim not a UUID", signToken(t, key, "k1", claims(jwt.MapClaims{"org_id": "acme"})), "", `"org_id" claim`},
+		{"org claim not a string", signToken(t, key, "k1", claims(jwt.MapClaims{"org_id": 42})), "", `"org_id" claim`},
+		{"no role claim", signToken(t, key, "k1", claims(jwt.MapClaims{"role": nil})), "", `"role" claim`},
+		{"unknown role", signToken(t, key, "k1", claims(jwt.MapClaims{"role": "owner"})), "", `"role" claim`},
+		{"expired", signToken(t, key, "k1", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})), "", "expired"},
+		{"no expiry", signToken(t, key, "k1", claims(jwt.MapClaims{"exp": nil})), "", "exp"},
+		{"wrong issuer", signToken(t, key, "k1", claims(jwt.MapClaims{"iss": "https://evil.example"})), "", "iss"},
+		{"unknown kid", signToken(t, key, "k2", claims(nil)), "", "unknown key id"},
+		{"wrong key", signToken(t, otherKey, "k1", claims(nil)), "", "signature"},
+		{"not a JWT", "smk_notajwt", "", "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			want := Principal{Subject: "alice", Role: tt.wantRole, Method: MethodJWT, OrgID: testOrg}
+			if p != want {
+				t.Errorf("Verify = %+v, want %+v", p, want)
+			}
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	tests := []struct {
+		name    string
+		jwks    string
+		wantErr string
+	}{
+		{"encryption keys only", `{"keys":[{"kty":"OKP","crv":"Ed25519","use":"enc","x":"AA"}]}`, "no signing keys"},
+		{"empty set", `{"keys":[]}`, "no signing keys"},
+		{"unsupported key type", `{"keys":[{"kty":"oct","kid":"a"}]}`, `unsupported key type "oct"`},
+		{"unsupported curve", `{"keys":[{"kty":"EC","kid":"a","crv":"P-192","x":"AA","y":"AA"}]}`, `unsupported curve "P-192"`},
+		{"short Ed25519 key", `{"keys":[{"kty":"OKP","kid":"a","crv":"Ed25519","x":"AAAA"}]}`, "invalid Ed25519 key"},
+		{"bad base64", `{"keys":[{"kty":"RSA","kid":"a","n":"!!","e":"AQAB"}]}`, "invalid base64url"},
+		{"not JSON", `keys`, "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJWKS([]byte(tt.jwks))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseJWKS error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/logger"
+	"site-monitor/pkg/utils"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	anonymous    = "anonymous"
+)
+
+type KeyStore interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (*storage.APIKey, error)
+}
+
+type Authenticator struct {
+	enabled bool
+	keys    KeyStore
+	jwt     *JWTVerifier
+	log     *logger.Logger
+}
+
+// With enabled false every request is an anonymous platform-wide admin.
+func NewAuthenticator(enabled bool, keys KeyStore, jwt *JWTVerifier, log *logger.Logger) *Authenticator {
+	return &Authenticator{enabled: enabled, keys: keys, jwt: jwt, log: log}
+}
+
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.enabled {
+			p := Principal{Subject: anonymous, Role: RoleAdmin, Method: MethodNone}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+			return
+		}
+
+		token := credentials(r)
+		if token == "" {
+			unauthorized(w, r, "missing credentials")
+			return
+		}
+
+		p, ok := a.authenticate(r.Context(), token)
+		if !ok {
+			unauthorized(w, r, "invalid credentials")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, token string) (Principal, bool) {
+	if strings.HasPrefix(token, KeyPrefix) {
+		key, err := a.keys.GetAPIKeyByHash(ctx, HashKey(token))
+		if err != nil {
+			a.log.Ctx(ctx).Errorw("Failed to look up API key", "error", err)
+			return Principal{}, false
+		}
+		if key == nil {
+			a.log.Ctx(ctx).Warnw("Rejected unknown or revoked API key", "prefix", token[:min(len(token), displayPrefixLen)])
+			return Principal{}, false
+		}
+		return Principal{Subject: key.Name, Role: key.Role, Method: MethodAPIKey, OrgID: key.OrgID}, true
+	}
+
+	if a.jwt == nil {
+		return Principal{}, false
+	}
+	p, err := a.jwt.Verify(ctx, token)
+	if err != nil {
+		a.log.Ctx(ctx).Warnw("Rejected JWT", "error", err)
+		return Principal{}, false
+	}
+	return p, true
+}
+
+func credentials(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+func Require(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFrom(r.Context())
+			if !ok {
+				unauthorized(w, r, "missing credentials")
+				return
+			}
+			if !Allows(p.Role, role) {
+				utils.WriteProblem(w, r, http.StatusForbidden, "requires "+role+" role")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func RequireGlobal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFrom(r.Context())
+		if !ok {
+			unauthorized(w, r, "missing credentials")
+			return
+		}
+		if !p.Global() {
+			utils.WriteProblem(w, r, http.StatusForbidden, "requires a platform-wide credential")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Reads need viewer, anything else needs editor.
+func RequireByMethod(next http.Handler) http.Handler {
+	viewer := Require(RoleViewer)(next)
+	editor := Require(RoleEditor)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			viewer.ServeHTTP(w, r)
+		default:
+			editor.ServeHTTP(w, r)
+		}
+	})
+}
+
+// The service role may record checker state without being able to edit.
+func RequireService(next http.Handler) http.Handler {
+	editor := Require(RoleEditor)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := PrincipalFrom(r.Context()); ok && p.Role == RoleService {
+			next.ServeHTTP(w, r)
+			return
+		}
+		editor.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="site-monitor"`)
+	utils.WriteProblem(w, r, http.StatusUnauthorized, msg)
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		have, need string
+		want       bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleEditor, false},
+		{RoleEditor, RoleViewer, true},
+		{RoleEditor, RoleAdmin, false},
+		{RoleAdmin, RoleEditor, true},
+		{RoleService, RoleViewer, true},
+		{RoleService, RoleEditor, false},
+		{"", RoleViewer, false},
+		{"owner", RoleViewer, false},
+		{"owner", "owner", false},
+	}
+	for _, tt := range tests {
+		if got := Allows(tt.have, tt.need); got != tt.want {
+			t.Errorf("Allows(%q, %q) = %t, want %t", tt.have, tt.need, got, tt.want)
+		}
+	}
+}
+
+func TestHighestRole(t *testing.T) {
+	tests := []struct {
+		name  string
+		claim interface{}
+		want  string
+	}{
+		{"single", "editor", RoleEditor},
+		{"list", []interface{}{"viewer", "admin", "editor"}, RoleAdmin},
+		{"unknown roles skipped", []interface{}{"owner", "viewer"}, RoleViewer},
+		{"non-string entries skipped", []interface{}{42, "editor"}, RoleEditor},
+		{"only unknown", "owner", ""},
+		{"missing", nil, ""},
+		{"wrong type", 3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highestRole(tt.claim); got != tt.want {
+				t.Errorf("highestRole(%v) = %q, want %q", tt.claim, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	byMethod := RequireByMethod(ok)
+	service := RequireService(ok)
+	admin := Require(RoleAdmin)(ok)
+	global := RequireGlobal(ok)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		role    string // empty means no principal at all
+		orgID   string
+		want    int
+	}{
+		{"read as viewer", byMethod, http.MethodGet, RoleViewer, "", http.StatusNoContent},
+		{"write as viewer", byMethod, http.MethodPost, RoleViewer, "", http.StatusForbidden},
+		{"write as editor", byMethod, http.MethodPatch, RoleEditor, "", http.StatusNoContent},
+		{"write as service", byMethod, http.MethodDelete, RoleService, "", http.StatusForbidden},
+		{"no principal", byMethod, http.MethodGet, "", "", http.StatusUnauthorized},
+		{"state as service", service, http.MethodPost, RoleService, "", http.StatusNoContent},
+		{"state as editor", service, http.MethodPost, RoleEditor, "", http.StatusNoContent},
+		{"state as viewer", service, http.MethodPost, RoleViewer, "", http.StatusForbidden},
+		{"state without principal", service, http.MethodPost, "", "", http.StatusUnauthorized},
+		{"admin as editor", admin, http.MethodGet, RoleEditor, "", http.StatusForbidden},
+		{"admin as admin", admin, http.MethodGet, RoleAdmin, "", http.StatusNoContent},
+		{"global as tenant admin", global, http.MethodGet, RoleAdmin, testOrg, http.StatusForbidden},
+		{"global as platform admin", global, http.MethodGet, RoleAdmin, "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/sites", nil)
+			if tt.role != "" {
+				r = r.WithContext(WithPrincipal(r.Context(), Principal{Subject: "test", Role: tt.role, OrgID: tt.orgID}))
+			}
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -1,21 +1,31 @@
 package checker
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/prometheus/client_golang/prometheus/push"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"site-monitor/internal/config"
 	"site-monitor/pkg/logger"
 	"site-monitor/pkg/metrics"
+	"site-monitor/pkg/tracing"
 )
 
+var tracer = otel.Tracer("site-monitor/internal/checker")
+
 func NewChecker(cfg config.CheckerConfig, log *logger.Logger) *Checker {
 	writer := &kafka.Writer{
 		Addr:     kafka.TCP(cfg.Kafka.Brokers...),
@@ -23,48 +33,101 @@ func NewChecker(cfg config.CheckerConfig, log *logger.Logger) *Checker {
 		Balancer: &kafka.LeastBytes{},
 	}
 
-	return &Checker{
-		cfg:         cfg,
-		apiURL:      cfg.Checker.ApiURL,
+	c := &Checker{
 		log:         log,
 		kafkaWriter: writer,
+		reloadCh:    make(chan struct{}, 1),
+		siteInfo:    metrics.NewSiteInfo(cfg.Prometheus.SiteLabels),
+	}
+	c.cfg.Store(&cfg)
+	return c
+}
+
+func (c *Checker) config() *config.CheckerConfig {
+	return c.cfg.Load()
+}
+
+func (c *Checker) UpdateConfig(cfg config.CheckerConfig) {
+	old := c.config()
+	if !reflect.DeepEqual(old.Kafka, cfg.Kafka) {
+		c.log.Sugar.Warnw("Kafka settings changed, restart the checker to apply them")
+		cfg.Kafka = old.Kafka
+	}
+	if !reflect.DeepEqual(old.Tracing, cfg.Tracing) {
+		c.log.Sugar.Warnw("Tracing settings changed, restart the checker to apply them")
+		cfg.Tracing = old.Tracing
+	}
+	if !reflect.DeepEqual(old.Prometheus.SiteLabels, cfg.Prometheus.SiteLabels) {
+		c.log.Sugar.Warnw("prometheus.site_labels changed, restart the checker to apply them")
+		cfg.Prometheus.SiteLabels = old.Prometheus.SiteLabels
+	}
+
+	c.cfg.Store(&cfg)
+	select {
+	case c.reloadCh <- struct{}{}:
+	default:
 	}
 }
 
 func (c *Checker) Run(ctx context.Context) {
-	ticker := time.NewTicker(time.Duration(c.cfg.Checker.Interval) * time.Second)
+	interval := c.config().Checker.Interval
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
 	defer ticker.Stop()
 
-	c.log.Sugar.Infow("Checker service started", "interval_sec", c.cfg.Checker.Interval)
+	c.log.Sugar.Infow("Checker service started", "interval_sec", interval)
+	metrics.CheckerUp.Set(1)
 
 	for {
 		select {
 		case <-ctx.Done():
+			metrics.CheckerUp.Set(0)
+			c.publishMetrics()
 			c.log.Sugar.Infow("Checker service stopped gracefully")
 			return
+		case <-c.reloadCh:
+			if next := c.config().Checker.Interval; next != interval {
+				interval = next
+				ticker.Reset(time.Duration(interval) * time.Second)
+				c.log.Sugar.Infow("Checker interval changed", "interval_sec", interval)
+			}
 		case <-ticker.C:
-			c.checkSites()
+			c.checkSites(ctx)
 		}
 	}
 }
 
-func (c *Checker) checkSites() {
+func (c *Checker) checkSites(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "checker.cycle")
+	defer span.End()
 
 	cycleStart := time.Now()
 	defer func() {
 		metrics.CheckerCycleDuration.Observe(time.Since(cycleStart).Seconds())
 		metrics.CheckerCycleTotal.Inc()
-		c.pushMetricsToPrometheus()
+		metrics.CheckerLastCycleTimestamp.SetToCurrentTime()
+		c.publishMetrics()
 	}()
 
-	sites, err := c.fetchSitesFromAPI()
+	sites, err := c.fetchSitesFromAPI(ctx)
 	if err != nil {
 		metrics.CheckerAPIErrors.Inc()
+		tracing.RecordError(span, err)
 		return
 	}
 
+	c.forgetRemovedSites(sites)
+	c.updateSiteInfo(sites)
+
+	windows := c.fetchActiveWindows(ctx)
+	c.markWindowsActive(ctx, windows)
+	ended := c.fetchEndedWindows(ctx, windows)
+	c.windowsMu.Lock()
+	c.windows = windows
+	c.windowsMu.Unlock()
+
 	metrics.CheckerSitesProcessed.Set(float64(len(sites)))
-	c.log.Sugar.Infow("Start checking sites", "count", len(sites))
+	span.SetAttributes(attribute.Int("sites", len(sites)))
+	c.log.Ctx(ctx).Infow("Start checking sites", "count", len(sites), "maintenance_windows", len(windows))
 
 	jobs := make(chan Site, len(sites))
 	results := make(chan SiteCheckResult, len(sites))
@@ -76,7 +139,13 @@ func (c *Checker) checkSites() {
 		go func() {
 			defer wg.Done()
 			for site := range jobs {
-				results <- c.CheckSite(site.URL)
+				window := matchWindow(windows, site)
+				if window != nil && window.Suppress == suppressChecks {
+					c.log.Ctx(ctx).Debugw("Site check skipped by maintenance window", "url", site.URL, "window", window.ID)
+					continue
+				}
+
+				results <- c.checkAndPublish(ctx, site, window)
 			}
 		}()
 	}
@@ -89,63 +158,178 @@ func (c *Checker) checkSites() {
 	wg.Wait()
 	close(results)
 
-	c.log.Sugar.Infow("Check cycle completed", "sites_checked", len(sites))
+	c.publishMaintenanceSummaries(ctx, ended, sites, results)
+
+	c.log.Ctx(ctx).Infow("Check cycle completed", "sites_checked", len(sites))
 }
 
-func (c *Checker) fetchSitesFromAPI() ([]Site, error) {
+// Each site gets its own trace, linked to the cycle span.
+func (c *Checker) checkAndPublish(cycleCtx context.Context, site Site, window *MaintenanceWindow) SiteCheckResult {
+	ctx, span := tracer.Start(cycleCtx, "checker.site",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(cycleCtx)),
+		trace.WithAttributes(attribute.String("site.id", site.ID), attribute.String("site.url", site.URL)),
+	)
+	defer span.End()
+
+	return c.checkAndSend(ctx, site, window)
+}
+
+func (c *Checker) checkAndSend(ctx context.Context, site Site, window *MaintenanceWindow) SiteCheckResult {
+	result := c.CheckSite(ctx, site)
+	if window != nil {
+		result.Maintenance = true
+		result.MaintenanceWindow = window.ID
+	}
+	c.sendToKafka(ctx, result)
+	return result
+}
+
+func (c *Checker) fetchSitesFromAPI(ctx context.Context) ([]Site, error) {
+	ctx, span := tracer.Start(ctx, "checker.fetchSitesFromAPI")
+	defer span.End()
+
+	cfg := c.config().Checker
 	client := http.Client{
-		Timeout: time.Duration(c.cfg.Checker.Timeout) * time.Second,
+		Timeout: time.Duration(cfg.Timeout) * time.Second,
 	}
 
-	resp, err := client.Get(c.apiURL + "/sites")
+	var sites []Site
+	cursor, pages := "", 0
+	for {
+		page, next, err := c.fetchSitesPage(ctx, &client, cfg.PageSize, cursor)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return nil, err
+		}
+		sites = append(sites, page...)
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	span.SetAttributes(attribute.Int("pages", pages))
+	return sites, nil
+}
+
+func (c *Checker) fetchSitesPage(ctx context.Context, client *http.Client, limit int, cursor string) ([]Site, string, error) {
+	q := url.Values{"limit": {strconv.Itoa(limit)}, "active": {"true"}}
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	req, err := c.newAPIRequest(ctx, http.MethodGet, "/sites?"+q.Encode(), nil)
 	if err != nil {
-		c.log.Sugar.Errorw("Failed to fetch sites from API", "error", err)
-		return nil, err
+		return nil, "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		c.log.Ctx(ctx).Errorw("Failed to fetch sites from API", "error", err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		c.log.Sugar.Errorw("API returned non-OK status", "status", resp.StatusCode)
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		c.log.Ctx(ctx).Errorw("API returned non-OK status", "status", resp.StatusCode)
+		return nil, "", fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 
 	var sites []Site
 	if err := json.NewDecoder(resp.Body).Decode(&sites); err != nil {
-		c.log.Sugar.Errorw("Failed to decode API response", "error", err)
+		c.log.Ctx(ctx).Errorw("Failed to decode API response", "error", err)
+		return nil, "", err
+	}
+	return sites, resp.Header.Get("X-Next-Cursor"), nil
+}
+
+func (c *Checker) newAPIRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	cfg := c.config().Checker
+	req, err := http.NewRequestWithContext(ctx, method, cfg.ApiURL+path, body)
+	if err != nil {
 		return nil, err
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	tracing.InjectHTTP(ctx, req)
+	if cfg.APIKey != "" {
+		req.Header.Set("X-API-Key", cfg.APIKey)
+	}
+	return req, nil
+}
 
-	return sites, nil
+func (c *Checker) apiClient() *http.Client {
+	return &http.Client{Timeout: time.Duration(c.config().Checker.Timeout) * time.Second}
+}
+
+func (c *Checker) postAPI(ctx context.Context, path string, body any) error {
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := c.newAPIRequest(ctx, http.MethodPost, path, r)
+	if err != nil {
+		return err
+	}
+	resp, err := c.apiClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	return nil
 }
 
-func (c *Checker) sendToKafka(result SiteCheckResult) {
+func (c *Checker) sendToKafka(ctx context.Context, result SiteCheckResult) {
+	ctx, span := tracer.Start(ctx, "checker.sendToKafka", trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
 	msg, err := json.Marshal(result)
 	if err != nil {
-		c.log.Sugar.Errorw("Failed to marshal JSON for Kafka", "url", result.URL, "error", err)
+		tracing.RecordError(span, err)
+		c.log.Ctx(ctx).Errorw("Failed to marshal JSON for Kafka", "url", result.URL, "error", err)
 		return
 	}
 
-	err = c.kafkaWriter.WriteMessages(context.Background(),
-		kafka.Message{
-			Key:   []byte(result.URL),
-			Value: []byte(msg),
-		},
-	)
+	message := kafka.Message{
+		Key:   []byte(result.URL),
+		Value: msg,
+	}
+	tracing.InjectKafka(ctx, &message)
+
+	err = c.kafkaWriter.WriteMessages(context.WithoutCancel(ctx), message)
 	if err != nil {
-		c.log.Sugar.Errorw("Failed to send message to Kafka", "url", result.URL, "error", err)
+		tracing.RecordError(span, err)
+		c.log.Ctx(ctx).Errorw("Failed to send message to Kafka", "url", result.URL, "error", err)
 	} else {
-		c.log.Sugar.Infow("Send message to Kafka", "url", result)
+		c.log.Ctx(ctx).Infow("Send message to Kafka", "url", result)
 	}
 }
 
-func (c *Checker) CheckSite(url string) SiteCheckResult {
+func (c *Checker) CheckSite(ctx context.Context, site Site) SiteCheckResult {
+	ctx, span := tracer.Start(ctx, "checker.CheckSite", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	url := site.URL
 	start := time.Now()
-	result := SiteCheckResult{URL: url, Timestamp: start}
+	result := SiteCheckResult{
+		SiteID: site.ID, OrgID: site.OrgID, URL: url, Tags: site.Tags, Team: site.Team,
+		Name: site.Name, Owner: site.Owner, RunbookURL: site.RunbookURL, Labels: site.Labels,
+		Timestamp: start,
+	}
 
-	client := http.Client{Timeout: time.Duration(c.cfg.Checker.Timeout) * time.Second}
-	resp, err := client.Get(url)
+	probe := c.probeHTTP(ctx, url, defaultCheckModule, time.Duration(c.config().Checker.Timeout)*time.Second)
+	err := probe.Err
 
-	result.ResponseTime = time.Since(start).Milliseconds()
+	result.ResponseTime = probe.Duration.Milliseconds()
 	statusCode := "unknown"
 
 	if err != nil {
@@ -156,48 +340,29 @@ func (c *Checker) CheckSite(url string) SiteCheckResult {
 		metrics.SiteCheckErrors.WithLabelValues(url, "connection_error").Inc()
 		metrics.SiteCheckSuccess.WithLabelValues(url).Set(0)
 
-		c.log.Sugar.Errorw("Site check failed",
+		tracing.RecordError(span, err)
+		c.log.Ctx(ctx).Errorw("Site check failed",
 			"url", url,
 			"error", err.Error(),
 			"response_time_ms", result.ResponseTime,
 		)
 
 	} else {
-		defer resp.Body.Close()
-		result.StatusCode = resp.StatusCode
-		statusCode = fmt.Sprintf("%d", resp.StatusCode)
+		result.StatusCode = probe.StatusCode
+		result.Success = probe.Success
+		statusCode = fmt.Sprintf("%d", probe.StatusCode)
 
 		metrics.SiteCheckSuccess.WithLabelValues(url).Set(1)
-		c.log.Sugar.Infow("Site check successed",
+		span.SetAttributes(attribute.Int("http.response.status_code", probe.StatusCode))
+		c.log.Sampled(ctx).Infow("Site check successed",
 			"url", url,
 			"status", result.StatusCode,
 			"response_time_ms", result.ResponseTime,
 		)
 	}
 
-	c.sendToKafka(result)
-
 	metrics.SiteCheckTotal.WithLabelValues(url, statusCode).Inc()
-	metrics.SiteCheckDuration.WithLabelValues(url, statusCode).Observe(float64(result.ResponseTime))
+	metrics.ObserveWithTrace(ctx, metrics.SiteCheckDuration.WithLabelValues(url, statusCode), float64(result.ResponseTime))
 
 	return result
 }
-
-func (c *Checker) pushMetricsToPrometheus() {
-	pusher := push.New(c.cfg.Prometheus.PushgatewayURL, "site_checker")
-
-	pusher.Collector(metrics.SiteCheckTotal)
-	pusher.Collector(metrics.SiteCheckSuccess)
-	pusher.Collector(metrics.SiteCheckErrors)
-	pusher.Collector(metrics.SiteCheckDuration)
-	pusher.Collector(metrics.CheckerCycleTotal)
-	pusher.Collector(metrics.CheckerCycleDuration)
-	pusher.Collector(metrics.CheckerSitesProcessed)
-	pusher.Collector(metrics.CheckerAPIErrors)
-
-	if err := pusher.Push(); err != nil {
-		c.log.Sugar.Errorw("Failed to push metrics to Pushgateway", "error", err)
-	} else {
-		c.log.Sugar.Debugw("Metrics pushed to Pushgateway successfully")
-	}
-}
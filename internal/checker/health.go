@@ -0,0 +1,18 @@
+package checker
+
+import (
+	"context"
+
+	"site-monitor/pkg/health"
+)
+
+// The CRUD API is checked through /livez so a Postgres outage on its side
+// doesn't make the checker unready as well.
+func (c *Checker) ReadinessChecks() map[string]health.Check {
+	return map[string]health.Check{
+		"crud_api": func(ctx context.Context) error {
+			return health.HTTP(c.config().Checker.ApiURL + "/livez")(ctx)
+		},
+		"kafka": health.Kafka(c.config().Kafka.Brokers),
+	}
+}
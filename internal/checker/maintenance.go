@@ -0,0 +1,190 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"site-monitor/pkg/tracing"
+)
+
+func (c *Checker) fetchActiveWindows(ctx context.Context) map[string]MaintenanceWindow {
+	client := c.apiClient()
+
+	req, err := c.newAPIRequest(ctx, http.MethodGet, "/maintenance/active", nil)
+	if err != nil {
+		c.log.Sugar.Errorw("Failed to build maintenance windows request, keeping previous ones", "error", err)
+		return c.lastWindows()
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		c.log.Sugar.Errorw("Failed to fetch maintenance windows, keeping previous ones", "error", err)
+		return c.lastWindows()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.log.Sugar.Errorw("Maintenance API returned non-OK status, keeping previous windows", "status", resp.StatusCode)
+		return c.lastWindows()
+	}
+
+	var list []MaintenanceWindow
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		c.log.Sugar.Errorw("Failed to decode maintenance windows, keeping previous ones", "error", err)
+		return c.lastWindows()
+	}
+
+	windows := make(map[string]MaintenanceWindow, len(list))
+	for _, w := range list {
+		windows[w.ID] = w
+	}
+	return windows
+}
+
+// On-demand checks read the last cycle's windows from other goroutines.
+func (c *Checker) lastWindows() map[string]MaintenanceWindow {
+	c.windowsMu.RLock()
+	defer c.windowsMu.RUnlock()
+	return c.windows
+}
+
+// The marks survive a restart, so a window that ends meanwhile still gets
+// its summary.
+func (c *Checker) markWindowsActive(ctx context.Context, windows map[string]MaintenanceWindow) {
+	if len(windows) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(windows))
+	for id := range windows {
+		ids = append(ids, id)
+	}
+	if err := c.postAPI(ctx, "/maintenance/seen", map[string][]string{"window_ids": ids}); err != nil {
+		c.log.Sugar.Errorw("Failed to mark maintenance windows active", "error", err)
+	}
+}
+
+// A window that ended after active was fetched waits for the next cycle, as
+// this cycle's checks still treated it as active.
+func (c *Checker) fetchEndedWindows(ctx context.Context, active map[string]MaintenanceWindow) []MaintenanceWindow {
+	req, err := c.newAPIRequest(ctx, http.MethodGet, "/maintenance/ended", nil)
+	if err != nil {
+		c.log.Sugar.Errorw("Failed to build ended maintenance windows request", "error", err)
+		return nil
+	}
+	client := c.apiClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		c.log.Sugar.Errorw("Failed to fetch ended maintenance windows", "error", err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.log.Sugar.Errorw("Maintenance API returned non-OK status for ended windows", "status", resp.StatusCode)
+		return nil
+	}
+
+	var list []MaintenanceWindow
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		c.log.Sugar.Errorw("Failed to decode ended maintenance windows", "error", err)
+		return nil
+	}
+
+	var ended []MaintenanceWindow
+	for _, w := range list {
+		if _, ok := active[w.ID]; !ok {
+			ended = append(ended, w)
+		}
+	}
+	return ended
+}
+
+func matchWindow(windows map[string]MaintenanceWindow, site Site) *MaintenanceWindow {
+	var match *MaintenanceWindow
+	for _, w := range windows {
+		if !windowMatches(w, site) {
+			continue
+		}
+		if w.Suppress == suppressChecks {
+			return &w
+		}
+		if match == nil {
+			match = &w
+		}
+	}
+	return match
+}
+
+func windowMatches(w MaintenanceWindow, site Site) bool {
+	if w.OrgID != site.OrgID {
+		return false
+	}
+	for _, id := range w.SiteIDs {
+		if id == site.ID {
+			return true
+		}
+	}
+	for _, tag := range w.Tags {
+		for _, siteTag := range site.Tags {
+			if tag == siteTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Checker) publishMaintenanceSummaries(ctx context.Context, ended []MaintenanceWindow, sites []Site, results <-chan SiteCheckResult) {
+	if len(ended) == 0 {
+		return
+	}
+
+	bySite := make(map[string]SiteCheckResult)
+	for r := range results {
+		bySite[r.SiteID] = r
+	}
+
+	for _, w := range ended {
+		summary := MaintenanceSummary{WindowID: w.ID, OrgID: w.OrgID, WindowName: w.Name, EndedAt: time.Now()}
+		for _, site := range sites {
+			r, ok := bySite[site.ID]
+			if !ok || !windowMatches(w, site) || r.StatusCode == http.StatusOK {
+				continue
+			}
+			summary.Failing = append(summary.Failing, r)
+		}
+
+		c.log.Sugar.Infow("Maintenance window ended", "window", w.ID, "failing", len(summary.Failing))
+		if len(summary.Failing) > 0 {
+			if err := c.sendMaintenanceSummary(ctx, summary); err != nil {
+				c.log.Sugar.Errorw("Failed to send maintenance summary to Kafka", "window", w.ID, "error", err)
+				continue
+			}
+		}
+
+		if err := c.postAPI(ctx, "/maintenance/"+w.ID+"/summary", nil); err != nil {
+			c.log.Sugar.Errorw("Failed to mark maintenance summary sent", "window", w.ID, "error", err)
+		}
+	}
+}
+
+func (c *Checker) sendMaintenanceSummary(ctx context.Context, summary MaintenanceSummary) error {
+	msg, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("marshal maintenance summary: %w", err)
+	}
+
+	message := kafka.Message{
+		Key:     []byte(summary.WindowID),
+		Value:   msg,
+		Headers: []kafka.Header{{Key: messageTypeHeader, Value: []byte(messageTypeMaintenanceEnd)}},
+	}
+	tracing.InjectKafka(ctx, &message)
+
+	return c.kafkaWriter.WriteMessages(context.WithoutCancel(ctx), message)
+}
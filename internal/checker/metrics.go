@@ -0,0 +1,82 @@
+package checker
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"site-monitor/internal/config"
+	"site-monitor/pkg/metrics"
+)
+
+// MetricsHandler answers 404 in push mode so series aren't collected twice.
+func (c *Checker) MetricsHandler() http.Handler {
+	h := promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.config().Prometheus.Mode != config.MetricsModePull {
+			http.Error(w, "metrics are pushed to Pushgateway, set prometheus.mode to pull to scrape them", http.StatusNotFound)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (c *Checker) publishMetrics() {
+	if c.config().Prometheus.Mode == config.MetricsModePush {
+		c.pushMetricsToPrometheus()
+	}
+}
+
+// Push replaces the whole job group, so Pushgateway forgets them too.
+func (c *Checker) forgetRemovedSites(sites []Site) {
+	current := make(map[string]struct{}, len(sites))
+	for _, s := range sites {
+		current[s.URL] = struct{}{}
+	}
+
+	for url := range c.knownURLs {
+		if _, ok := current[url]; !ok {
+			metrics.DeleteSiteSeries(url)
+			c.siteInfo.DeletePartialMatch(prometheus.Labels{"url": url})
+			c.log.Sugar.Infow("Removed metrics of deleted site", "url", url)
+		}
+	}
+	c.knownURLs = current
+}
+
+// Any label value may have changed, so the old series goes first.
+func (c *Checker) updateSiteInfo(sites []Site) {
+	keys := c.config().Prometheus.SiteLabels
+	for _, s := range sites {
+		labels := prometheus.Labels{"url": s.URL, "name": s.Name, "owner": s.Owner, "team": s.Team}
+		for _, k := range keys {
+			labels[metrics.SiteInfoLabel(k)] = s.Labels[k]
+		}
+		c.siteInfo.DeletePartialMatch(prometheus.Labels{"url": s.URL})
+		c.siteInfo.With(labels).Set(1)
+	}
+}
+
+func (c *Checker) pushMetricsToPrometheus() {
+	pusher := push.New(c.config().Prometheus.PushgatewayURL, "site_checker")
+
+	pusher.Collector(metrics.SiteCheckTotal)
+	pusher.Collector(metrics.SiteCheckSuccess)
+	pusher.Collector(metrics.SiteCheckErrors)
+	pusher.Collector(metrics.SiteCheckDuration)
+	pusher.Collector(c.siteInfo)
+	pusher.Collector(metrics.CheckerCycleTotal)
+	pusher.Collector(metrics.CheckerCycleDuration)
+	pusher.Collector(metrics.CheckerSitesProcessed)
+	pusher.Collector(metrics.CheckerAPIErrors)
+	pusher.Collector(metrics.CheckerUp)
+	pusher.Collector(metrics.CheckerLastCycleTimestamp)
+
+	if err := pusher.Push(); err != nil {
+		c.log.Sugar.Errorw("Failed to push metrics to Pushgateway", "error", err)
+	} else {
+		c.log.Sugar.Debugw("Metrics pushed to Pushgateway successfully")
+	}
+}
@@ -0,0 +1,85 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errSiteNotFound = errors.New("site not found")
+
+type checkRequest struct {
+	SiteID string `json:"site_id"`
+}
+
+// CheckHandler loads the site from the API rather than taking it from the
+// caller, so it can't be used to probe arbitrary URLs. A window that
+// suppresses checks doesn't stop an explicit one.
+func (c *Checker) CheckHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body checkRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.SiteID == "" {
+			http.Error(w, "Body must be {\"site_id\": \"...\"}", http.StatusBadRequest)
+			return
+		}
+
+		ctx, span := tracer.Start(r.Context(), "checker.checkNow",
+			trace.WithAttributes(attribute.String("site.id", body.SiteID)))
+		defer span.End()
+
+		site, err := c.fetchSite(ctx, body.SiteID)
+		switch {
+		case errors.Is(err, errSiteNotFound):
+			http.Error(w, "Site not found", http.StatusNotFound)
+			return
+		case err != nil:
+			c.log.Ctx(ctx).Errorw("Failed to fetch site for on-demand check", "site_id", body.SiteID, "error", err)
+			http.Error(w, "Failed to fetch site from API", http.StatusBadGateway)
+			return
+		case !site.Active:
+			http.Error(w, "Site is paused", http.StatusConflict)
+			return
+		}
+
+		window := matchWindow(c.fetchActiveWindows(ctx), *site)
+		result := c.checkAndSend(ctx, *site, window)
+		c.log.Ctx(ctx).Infow("On-demand check done", "url", site.URL, "status", result.StatusCode, "success", result.Success)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			c.log.Ctx(ctx).Errorw("Failed to write check result", "url", site.URL, "error", err)
+		}
+	})
+}
+
+func (c *Checker) fetchSite(ctx context.Context, id string) (*Site, error) {
+	req, err := c.newAPIRequest(ctx, http.MethodGet, "/sites/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.apiClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusBadRequest:
+		return nil, errSiteNotFound
+	default:
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var site Site
+	if err := json.NewDecoder(resp.Body).Decode(&site); err != nil {
+		return nil, err
+	}
+	return &site, nil
+}
@@ -0,0 +1,319 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"site-monitor/internal/config"
+	"site-monitor/pkg/tracing"
+)
+
+const (
+	defaultProbeModule = "http_2xx"
+
+	// scrapeTimeoutOffset leaves Prometheus time to receive the response
+	// before its own scrape timeout fires, as blackbox_exporter does.
+	scrapeTimeoutOffset = 500 * time.Millisecond
+
+	maxProbeRedirects = 10
+)
+
+var defaultCheckModule = config.ProbeModule{Prober: config.ProberHTTP}
+
+var errTooManyRedirects = errors.New("stopped after 10 redirects")
+
+type probeResult struct {
+	Success          bool
+	StatusCode       int
+	Err              error
+	Duration         time.Duration
+	ContentLength    int64
+	Redirects        int
+	HTTPVersion      float64
+	TLS              *tls.ConnectionState
+	Phases           map[string]time.Duration
+	FailedDueToRegex bool
+}
+
+// phaseTimer collects httptrace timestamps. Callbacks can fire on the dialer
+// goroutine, hence the mutex.
+type phaseTimer struct {
+	mu                        sync.Mutex
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	gotConn, firstByte        time.Time
+}
+
+func (t *phaseTimer) mark(ts *time.Time) {
+	t.mu.Lock()
+	*ts = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *phaseTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart:         func(string, string) { t.mark(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { t.mark(&t.connectDone) },
+		TLSHandshakeStart:    func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.mark(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { t.mark(&t.gotConn) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+}
+
+func (t *phaseTimer) phases(end time.Time) map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.Before(from) {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	return map[string]time.Duration{
+		"resolve":    span(t.dnsStart, t.dnsDone),
+		"connect":    span(t.connectStart, t.connectDone),
+		"tls":        span(t.tlsStart, t.tlsDone),
+		"processing": span(t.gotConn, t.firstByte),
+		"transfer":   span(t.firstByte, end),
+	}
+}
+
+func (c *Checker) probeHTTP(ctx context.Context, target string, module config.ProbeModule, timeout time.Duration) probeResult {
+	start := time.Now()
+	result := probeResult{}
+	timer := &phaseTimer{}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method := module.HTTP.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if module.HTTP.Body != "" {
+		body = strings.NewReader(module.HTTP.Body)
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, timer.trace()), method, target, body)
+	if err != nil {
+		result.Err = err
+		result.Duration = time.Since(start)
+		return result
+	}
+	for k, v := range module.HTTP.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	client := http.Client{
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			result.Redirects = len(via)
+			if module.HTTP.NoFollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= maxProbeRedirects {
+				return errTooManyRedirects
+			}
+			return nil
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		result.Duration = time.Since(start)
+		result.Phases = timer.phases(time.Now())
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.ContentLength = resp.ContentLength
+	result.HTTPVersion = float64(resp.ProtoMajor) + float64(resp.ProtoMinor)/10
+	result.TLS = resp.TLS
+	result.Success = validStatus(module.HTTP.ValidStatusCodes, resp.StatusCode)
+
+	if module.HTTP.FailIfSSL && resp.TLS != nil {
+		result.Success = false
+	}
+	if module.HTTP.FailIfNotSSL && resp.TLS == nil {
+		result.Success = false
+	}
+
+	if len(module.HTTP.FailIfBodyMatchesRegexp) > 0 || len(module.HTTP.FailIfBodyNotMatchesRegexp) > 0 {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			result.Err = err
+			result.Success = false
+		} else if !bodyMatches(module.HTTP, b) {
+			result.FailedDueToRegex = true
+			result.Success = false
+		}
+	}
+
+	end := time.Now()
+	result.Duration = end.Sub(start)
+	result.Phases = timer.phases(end)
+	return result
+}
+
+func validStatus(valid []int, code int) bool {
+	if len(valid) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, v := range valid {
+		if v == code {
+			return true
+		}
+	}
+	return false
+}
+
+func bodyMatches(cfg config.ProbeHTTPConfig, body []byte) bool {
+	for _, re := range cfg.FailIfBodyMatchesRegexp {
+		if re.Match(body) {
+			return false
+		}
+	}
+	for _, re := range cfg.FailIfBodyNotMatchesRegexp {
+		if !re.Match(body) {
+			return false
+		}
+	}
+	return true
+}
+
+// ProbeHandler speaks the blackbox_exporter /probe protocol.
+func (c *Checker) ProbeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := c.config()
+
+		moduleName := r.URL.Query().Get("module")
+		if moduleName == "" {
+			moduleName = defaultProbeModule
+		}
+		module, ok := cfg.Probe.Modules[moduleName]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Unknown module %q", moduleName), http.StatusBadRequest)
+			return
+		}
+
+		target := r.URL.Query().Get("target")
+		if target == "" {
+			http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+			return
+		}
+		if !strings.Contains(target, "://") {
+			target = "http://" + target
+		}
+
+		timeout, err := probeTimeout(r, module, cfg.Checker.Timeout)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx, span := tracer.Start(r.Context(), "checker.probe",
+			trace.WithAttributes(attribute.String("probe.target", target), attribute.String("probe.module", moduleName)))
+		defer span.End()
+
+		result := c.probeHTTP(ctx, target, module, timeout)
+		if result.Err != nil {
+			tracing.RecordError(span, result.Err)
+			c.log.Ctx(ctx).Debugw("Probe failed", "target", target, "module", moduleName, "error", result.Err)
+		}
+
+		registry := prometheus.NewRegistry()
+		registerProbeMetrics(registry, module, result)
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+func probeTimeout(r *http.Request, module config.ProbeModule, defaultSec int) (time.Duration, error) {
+	timeout := time.Duration(module.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = time.Duration(defaultSec) * time.Second
+	}
+
+	if v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); v != "" {
+		sec, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse timeout from Prometheus header: %w", err)
+		}
+		scrape := time.Duration(sec*float64(time.Second)) - scrapeTimeoutOffset
+		if scrape > 0 && scrape < timeout {
+			timeout = scrape
+		}
+	}
+	return timeout, nil
+}
+
+func registerProbeMetrics(reg *prometheus.Registry, module config.ProbeModule, result probeResult) {
+	gauge := func(name, help string, v float64) {
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Name: name, Help: help})
+		g.Set(v)
+		reg.MustRegister(g)
+	}
+	boolValue := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	gauge("probe_success", "Displays whether or not the probe was a success", boolValue(result.Success))
+	gauge("probe_duration_seconds", "Returns how long the probe took to complete in seconds", result.Duration.Seconds())
+	gauge("probe_http_status_code", "Response HTTP status code", float64(result.StatusCode))
+	gauge("probe_http_content_length", "Length of http content response", float64(result.ContentLength))
+	gauge("probe_http_redirects", "The number of redirects", float64(result.Redirects))
+	gauge("probe_http_ssl", "Indicates if SSL was used for the final redirect", boolValue(result.TLS != nil))
+	gauge("probe_http_version", "Returns the version of HTTP of the probe response", result.HTTPVersion)
+	gauge("probe_dns_lookup_time_seconds", "Returns the time taken for probe dns lookup in seconds", result.Phases["resolve"].Seconds())
+
+	phases := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_http_duration_seconds",
+		Help: "Duration of http request by phase",
+	}, []string{"phase"})
+	for phase, d := range result.Phases {
+		phases.WithLabelValues(phase).Set(d.Seconds())
+	}
+	reg.MustRegister(phases)
+
+	if result.TLS != nil && len(result.TLS.PeerCertificates) > 0 {
+		earliest := result.TLS.PeerCertificates[0].NotAfter
+		for _, cert := range result.TLS.PeerCertificates[1:] {
+			if cert.NotAfter.Before(earliest) {
+				earliest = cert.NotAfter
+			}
+		}
+		gauge("probe_ssl_earliest_cert_expiry", "Returns last SSL chain expiry in unixtime", float64(earliest.Unix()))
+	}
+
+	if len(module.HTTP.FailIfBodyMatchesRegexp) > 0 || len(module.HTTP.FailIfBodyNotMatchesRegexp) > 0 {
+		gauge("probe_failed_due_to_regex", "Indicates if probe failed due to regex", boolValue(result.FailedDueToRegex))
+	}
+}
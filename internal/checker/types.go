@@ -1,8 +1,11 @@
 package checker
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/segmentio/kafka-go"
 
 	"site-monitor/internal/config"
@@ -11,24 +14,71 @@ import (
 
 const workerCount = 25
 
+const (
+	messageTypeHeader         = "type"
+	messageTypeMaintenanceEnd = "maintenance_summary"
+
+	suppressChecks = "checks"
+)
+
 type Checker struct {
-	cfg         config.CheckerConfig
-	apiURL      string
+	cfg         atomic.Pointer[config.CheckerConfig]
 	log         *logger.Logger
 	kafkaWriter *kafka.Writer
+	windowsMu   sync.RWMutex
+	windows     map[string]MaintenanceWindow
+	knownURLs   map[string]struct{}
+	reloadCh    chan struct{}
+	// Built once: prometheus.site_labels needs a restart to change.
+	siteInfo *prometheus.GaugeVec
 }
 
 type SiteCheckResult struct {
-	URL          string    `json:"url"`
-	StatusCode   int       `json:"status"`
-	ResponseTime int64     `json:"response_time_ms"`
-	Success      bool      `json:"success"`
-	Timestamp    time.Time `json:"timestamp"`
-	ErrorMsg     string    `json:"error"`
+	SiteID            string            `json:"site_id"`
+	OrgID             string            `json:"org_id"`
+	URL               string            `json:"url"`
+	Tags              []string          `json:"tags,omitempty"`
+	Team              string            `json:"team,omitempty"`
+	Name              string            `json:"name,omitempty"`
+	Owner             string            `json:"owner,omitempty"`
+	RunbookURL        string            `json:"runbook_url,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	StatusCode        int               `json:"status"`
+	ResponseTime      int64             `json:"response_time_ms"`
+	Success           bool              `json:"success"`
+	Timestamp         time.Time         `json:"timestamp"`
+	ErrorMsg          string            `json:"error"`
+	Maintenance       bool              `json:"maintenance"`
+	MaintenanceWindow string            `json:"maintenance_window,omitempty"`
 }
 
 type Site struct {
-	ID     string `json:"id"`
-	URL    string `json:"url"`
-	Active bool   `json:"active"`
+	ID     string   `json:"id"`
+	OrgID  string   `json:"org_id"`
+	URL    string   `json:"url"`
+	Active bool     `json:"active"`
+	Tags   []string `json:"tags"`
+	Team   string   `json:"team"`
+
+	Name       string            `json:"name"`
+	Owner      string            `json:"owner"`
+	RunbookURL string            `json:"runbook_url"`
+	Labels     map[string]string `json:"labels"`
+}
+
+type MaintenanceWindow struct {
+	ID       string   `json:"id"`
+	OrgID    string   `json:"org_id"`
+	Name     string   `json:"name"`
+	SiteIDs  []string `json:"site_ids"`
+	Tags     []string `json:"tags"`
+	Suppress string   `json:"suppress"`
+}
+
+type MaintenanceSummary struct {
+	WindowID   string            `json:"window_id"`
+	OrgID      string            `json:"org_id"`
+	WindowName string            `json:"window_name"`
+	EndedAt    time.Time         `json:"ended_at"`
+	Failing    []SiteCheckResult `json:"failing"`
 }
@@ -1,12 +1,59 @@
 package config
 
 import (
+	"flag"
+	"fmt"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
-func LoadConfig(path string, out interface{}) error {
+const envPrefix = "SITEMON"
+
+var configPath = flag.String("config", "", "path to the service config file (overrides CONFIG_PATH)")
+
+type Validator interface {
+	Validate() error
+}
+
+func Load(defaultPath string, out Validator) error {
+	if err := applyDefaults(reflect.ValueOf(out).Elem()); err != nil {
+		return fmt.Errorf("apply defaults: %w", err)
+	}
+
+	path := ResolvePath(defaultPath)
+	if err := readYAML(path, out); err != nil {
+		return fmt.Errorf("load %s: %w", path, err)
+	}
+
+	if err := applyEnv(reflect.ValueOf(out).Elem(), envPrefix); err != nil {
+		return fmt.Errorf("apply environment overrides: %w", err)
+	}
+
+	if err := resolveSecrets(reflect.ValueOf(out).Elem(), ""); err != nil {
+		return fmt.Errorf("resolve secrets: %w", err)
+	}
+
+	return out.Validate()
+}
+
+func ResolvePath(defaultPath string) string {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+	if *configPath != "" {
+		return *configPath
+	}
+	if p := os.Getenv("CONFIG_PATH"); p != "" {
+		return p
+	}
+	return defaultPath
+}
+
+func readYAML(path string, out interface{}) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -18,3 +65,96 @@ func LoadConfig(path string, out interface{}) error {
 	}
 	return nil
 }
+
+func applyDefaults(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		sf := t.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyDefaults(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		def, ok := sf.Tag.Lookup("default")
+		if !ok || !field.IsZero() {
+			continue
+		}
+		if err := setFromString(field, def); err != nil {
+			return fmt.Errorf("%s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		sf := t.Field(i)
+
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + "_" + strings.ToUpper(name)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		val, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setFromString(field, val); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func setFromString(field reflect.Value, s string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"site-monitor/pkg/logger"
+)
+
+const (
+	SecretKeyEnv = "SITEMON_SECRET_KEY"
+
+	filePrefix      = "file:"
+	encryptedPrefix = "enc:"
+)
+
+var secretRef = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+
+func resolveSecrets(v reflect.Value, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		sf := t.Field(i)
+
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldPath := strings.TrimPrefix(path+"."+name, ".")
+
+		switch field.Kind() {
+		case reflect.Struct:
+			if err := resolveSecrets(field, fieldPath); err != nil {
+				return err
+			}
+		case reflect.String:
+			resolved, err := resolveValue(field.String())
+			if err != nil {
+				return fmt.Errorf("%s: %w", fieldPath, err)
+			}
+			field.SetString(resolved)
+			if sf.Tag.Get("secret") == "true" {
+				registerSecret(resolved)
+			}
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.String {
+				continue
+			}
+			for j := 0; j < field.Len(); j++ {
+				resolved, err := resolveValue(field.Index(j).String())
+				if err != nil {
+					return fmt.Errorf("%s[%d]: %w", fieldPath, j, err)
+				}
+				field.Index(j).SetString(resolved)
+			}
+		case reflect.Map:
+			if err := resolveMapSecrets(field, fieldPath, sf.Tag.Get("secret") == "true"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveMapSecrets handles maps keyed by name, such as probe modules and
+// their headers. Map values aren't addressable, so each one is copied,
+// resolved and stored back.
+func resolveMapSecrets(field reflect.Value, path string, secret bool) error {
+	if field.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+
+	iter := field.MapRange()
+	for iter.Next() {
+		elemPath := path + "." + iter.Key().String()
+		elem := reflect.New(field.Type().Elem()).Elem()
+		elem.Set(iter.Value())
+
+		switch elem.Kind() {
+		case reflect.Struct:
+			if err := resolveSecrets(elem, elemPath); err != nil {
+				return err
+			}
+		case reflect.String:
+			resolved, err := resolveValue(elem.String())
+			if err != nil {
+				return fmt.Errorf("%s: %w", elemPath, err)
+			}
+			elem.SetString(resolved)
+			if secret {
+				registerSecret(resolved)
+			}
+		default:
+			continue
+		}
+		field.SetMapIndex(iter.Key(), elem)
+	}
+	return nil
+}
+
+func resolveValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, filePrefix):
+		secret, err := readSecretFile(strings.TrimPrefix(s, filePrefix))
+		if err != nil {
+			return "", err
+		}
+		return secret, nil
+	case strings.HasPrefix(s, encryptedPrefix):
+		secret, err := decryptSecret(strings.TrimPrefix(s, encryptedPrefix))
+		if err != nil {
+			return "", err
+		}
+		return secret, nil
+	}
+
+	var resolveErr error
+	resolved := secretRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := secretRef.FindStringSubmatch(ref)
+		var secret string
+		switch m[1] {
+		case "env":
+			v, ok := os.LookupEnv(m[2])
+			if !ok {
+				resolveErr = errors.Join(resolveErr, fmt.Errorf("environment variable %s is not set", m[2]))
+			}
+			secret = v
+		case "file":
+			v, err := readSecretFile(m[2])
+			if err != nil {
+				resolveErr = errors.Join(resolveErr, err)
+			}
+			secret = v
+		}
+		return secret
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func registerSecret(secret string) {
+	logger.RegisterSecret(secret)
+	if u, err := url.Parse(secret); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			logger.RegisterSecret(password)
+		}
+	}
+}
+
+func secretKey() ([]byte, error) {
+	raw := os.Getenv(SecretKeyEnv)
+	if raw == "" {
+		return nil, fmt.Errorf("%s is not set", SecretKeyEnv)
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be base64 encoded: %w", SecretKeyEnv, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s must be a 32-byte key, got %d bytes", SecretKeyEnv, len(key))
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func decryptSecret(encoded string) (string, error) {
+	key, err := secretKey()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode encrypted secret: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("encrypted secret is too short")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func EncryptSecret(plaintext string) (string, error) {
+	key, err := secretKey()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"site-monitor/pkg/logger"
+)
+
+func TestResolveValue(t *testing.T) {
+	key, err := GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SecretKeyEnv, key)
+	t.Setenv("SITEMON_TEST_PASSWORD", "hunter22")
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := EncryptSecret("bot:123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "kafka:9092", "kafka:9092"},
+		{"env", "${env:SITEMON_TEST_PASSWORD}", "hunter22"},
+		{"env inside a DSN", "postgres://u:${env:SITEMON_TEST_PASSWORD}@db/x", "postgres://u:hunter22@db/x"},
+		{"file reference", "${file:" + file + "}", "from-file"},
+		{"whole value from file", "file:" + file, "from-file"},
+		{"encrypted", encrypted, "bot:123"},
+		{"unknown reference kind is kept", "${vault:x}", "${vault:x}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveValue(tt.in)
+			if err != nil {
+				t.Fatalf("resolveValue(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveValue(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveValueErrors(t *testing.T) {
+	key, err := GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SecretKeyEnv, key)
+	encrypted, err := EncryptSecret("bot:123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, in, key string
+	}{
+		{"unset env", "${env:SITEMON_TEST_UNSET}", key},
+		{"missing file", "file:/nonexistent/secret", key},
+		{"missing file reference", "${file:/nonexistent/secret}", key},
+		{"not base64", "enc:***", key},
+		{"too short", "enc:AAAA", key},
+		{"other key", encrypted, otherKey},
+		{"no key", encrypted, ""},
+		{"short key", encrypted, "c2hvcnQ="},
+		{"tampered", encrypted[:len(encrypted)-4] + "AAAA", key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SecretKeyEnv, tt.key)
+			if got, err := resolveValue(tt.in); err == nil {
+				t.Errorf("resolveValue(%q) = %q, expected an error", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestEncryptSecretRoundTrip(t *testing.T) {
+	key, err := GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SecretKeyEnv, key)
+
+	for _, plaintext := range []string{"", "bot:123", "пароль with spaces\nand a newline"} {
+		a, err := EncryptSecret(plaintext)
+		if err != nil {
+			t.Fatalf("EncryptSecret(%q): %v", plaintext, err)
+		}
+		b, err := EncryptSecret(plaintext)
+		if err != nil {
+			t.Fatalf("EncryptSecret(%q): %v", plaintext, err)
+		}
+		if a == b {
+			t.Errorf("EncryptSecret(%q) returned the same ciphertext twice", plaintext)
+		}
+		if !strings.HasPrefix(a, encryptedPrefix) {
+			t.Errorf("EncryptSecret(%q) = %q, want the %q prefix", plaintext, a, encryptedPrefix)
+		}
+		got, err := resolveValue(a)
+		if err != nil {
+			t.Fatalf("resolveValue(%q): %v", a, err)
+		}
+		if got != plaintext {
+			t.Errorf("round trip = %q, want %q", got, plaintext)
+		}
+	}
+}
+
+func TestResolveSecretsRedactsOnlySecretFields(t *testing.T) {
+	t.Setenv("SITEMON_TEST_TOPIC", "topic-not-secret-1f3a")
+	t.Setenv("SITEMON_TEST_TOKEN", "token-secret-1f3a")
+	t.Setenv("SITEMON_TEST_DSN_PASSWORD", "dsn-password-1f3a")
+	t.Setenv("SITEMON_TEST_HEADER", "header-secret-1f3a")
+
+	var cfg struct {
+		Topic  string `yaml:"topic"`
+		Token  string `yaml:"token" secret:"true"`
+		Nested struct {
+			DSN string `yaml:"dsn" secret:"true"`
+		} `yaml:"nested"`
+		Modules map[string]ProbeModule `yaml:"modules"`
+	}
+	cfg.Topic = "${env:SITEMON_TEST_TOPIC}"
+	cfg.Token = "${env:SITEMON_TEST_TOKEN}"
+	cfg.Nested.DSN = "postgres://u:${env:SITEMON_TEST_DSN_PASSWORD}@db/x"
+	cfg.Modules = map[string]ProbeModule{
+		"http_2xx": {HTTP: ProbeHTTPConfig{Headers: map[string]string{"Authorization": "${env:SITEMON_TEST_HEADER}"}}},
+	}
+
+	if err := resolveSecrets(reflect.ValueOf(&cfg).Elem(), ""); err != nil {
+		t.Fatalf("resolveSecrets: %v", err)
+	}
+	if got := cfg.Modules["http_2xx"].HTTP.Headers["Authorization"]; got != "header-secret-1f3a" {
+		t.Errorf("header = %q, want it resolved", got)
+	}
+
+	tests := []struct {
+		value  string
+		secret bool
+	}{
+		{"topic-not-secret-1f3a", false},
+		{"token-secret-1f3a", true},
+		{"dsn-password-1f3a", true},
+		{"header-secret-1f3a", true},
+	}
+	for _, tt := range tests {
+		redacted := logger.Redact("value="+tt.value) != "value="+tt.value
+		if redacted != tt.secret {
+			t.Errorf("%q redacted = %t, want %t", tt.value, redacted, tt.secret)
+		}
+	}
+}
@@ -1,46 +1,195 @@
 package config
 
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"site-monitor/pkg/logger"
+	"site-monitor/pkg/tracing"
+)
+
+const (
+	MetricsModePush = "push"
+	MetricsModePull = "pull"
+
+	ProberHTTP = "http"
+)
+
 type CheckerConfig struct {
+	Server struct {
+		Port int `yaml:"port" default:"9101"`
+		// AdminToken is the bearer token for /admin/log-level, /probe and
+		// POST /check. They are off while it is empty.
+		AdminToken string `yaml:"admin_token" secret:"true"`
+	} `yaml:"server"`
+
 	Checker struct {
-		Timeout  int    `yaml:"timeout"`
-		Interval int    `yaml:"interval"`
+		Timeout  int    `yaml:"timeout" default:"5"`
+		Interval int    `yaml:"interval" default:"60"`
 		ApiURL   string `yaml:"api_url"`
+		APIKey   string `yaml:"api_key" secret:"true"`
+		// PageSize is how many sites each GET /sites request asks for.
+		PageSize int `yaml:"page_size" default:"500"`
 	} `yaml:"checker"`
 
 	Kafka struct {
 		Brokers []string `yaml:"brokers"`
-		Topic   string   `yaml:"topic"`
+		Topic   string   `yaml:"topic" default:"site_alerts"`
 	} `yaml:"kafka"`
 
 	Prometheus struct {
+		Mode           string `yaml:"mode" default:"push"`
 		PushgatewayURL string `yaml:"pushgateway_url"`
+		// SiteLabels are the site label keys exported on site_info. Every
+		// key is a label of every series, so keep the list short.
+		SiteLabels []string `yaml:"site_labels"`
 	} `yaml:"prometheus"`
+
+	Probe struct {
+		Modules map[string]ProbeModule `yaml:"modules"`
+	} `yaml:"probe"`
+
+	Logging logger.Config  `yaml:"logging"`
+	Tracing tracing.Config `yaml:"tracing"`
+}
+
+// ProbeModule mirrors the http prober module of blackbox_exporter, so modules
+// can be copied from an existing blackbox.yml with the timeout in seconds.
+type ProbeModule struct {
+	Prober  string          `yaml:"prober"`
+	Timeout int             `yaml:"timeout"`
+	HTTP    ProbeHTTPConfig `yaml:"http"`
+}
+
+type ProbeHTTPConfig struct {
+	Method                     string            `yaml:"method"`
+	Headers                    map[string]string `yaml:"headers" secret:"true"`
+	Body                       string            `yaml:"body"`
+	ValidStatusCodes           []int             `yaml:"valid_status_codes"`
+	NoFollowRedirects          bool              `yaml:"no_follow_redirects"`
+	FailIfSSL                  bool              `yaml:"fail_if_ssl"`
+	FailIfNotSSL               bool              `yaml:"fail_if_not_ssl"`
+	FailIfBodyMatchesRegexp    []Regexp          `yaml:"fail_if_body_matches_regexp"`
+	FailIfBodyNotMatchesRegexp []Regexp          `yaml:"fail_if_body_not_matches_regexp"`
+}
+
+// Regexp is compiled when the config is read, so probes don't compile it on
+// every request.
+type Regexp struct {
+	*regexp.Regexp
+}
+
+func (r *Regexp) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return fmt.Errorf("invalid regexp %q: %w", s, err)
+	}
+	r.Regexp = re
+	return nil
 }
 
 type CrudConfig struct {
 	Server struct {
-		Port int `yaml:"port"`
+		Port int `yaml:"port" default:"8080"`
 	} `yaml:"server"`
 	Postgres struct {
-		DSN string `yaml:"dsn"`
+		DSN string `yaml:"dsn" secret:"true"`
 	} `yaml:"postgres"`
+
+	Auth struct {
+		Enabled bool      `yaml:"enabled" default:"true"`
+		JWT     JWTConfig `yaml:"jwt"`
+	} `yaml:"auth"`
+
+	Audit AuditConfig `yaml:"audit"`
+
+	// Checker is the checker service's admin endpoint, used by
+	// POST /sites/{id}/check. Token is the checker's server.admin_token.
+	// On-demand checks are off while either is empty.
+	Checker struct {
+		URL     string `yaml:"url"`
+		Token   string `yaml:"token" secret:"true"`
+		Timeout int    `yaml:"timeout" default:"60"`
+	} `yaml:"checker"`
+
+	Logging logger.Config  `yaml:"logging"`
+	Tracing tracing.Config `yaml:"tracing"`
+}
+
+// JWTConfig enables bearer JWTs next to API keys. Keys are taken from a JWKS
+// served at JWKSURL or stored in JWKSFile.
+type JWTConfig struct {
+	JWKSURL         string `yaml:"jwks_url"`
+	JWKSFile        string `yaml:"jwks_file"`
+	Issuer          string `yaml:"issuer"`
+	Audience        string `yaml:"audience"`
+	RoleClaim       string `yaml:"role_claim" default:"role"`
+	OrgClaim        string `yaml:"org_claim" default:"org_id"`
+	RefreshInterval int    `yaml:"refresh_interval" default:"300"`
+}
+
+func (j JWTConfig) Enabled() bool {
+	return j.JWKSURL != "" || j.JWKSFile != ""
+}
+
+// AuditConfig optionally streams audit events to Kafka in addition to the
+// audit_log table. The stream is off while Kafka.Brokers is empty.
+type AuditConfig struct {
+	Kafka struct {
+		Brokers []string `yaml:"brokers"`
+		Topic   string   `yaml:"topic" default:"audit_events"`
+	} `yaml:"kafka"`
+}
+
+func (a AuditConfig) StreamEnabled() bool {
+	return len(a.Kafka.Brokers) > 0
 }
 
 type AlertConfig struct {
+	Server struct {
+		Port int `yaml:"port" default:"9102"`
+		// AdminToken is the bearer token for /admin/log-level. The endpoint
+		// is off while it is empty.
+		AdminToken string `yaml:"admin_token" secret:"true"`
+	} `yaml:"server"`
+
 	Kafka struct {
 		Brokers []string `yaml:"brokers"`
-		Topic   string   `yaml:"topic"`
-		GroupID string   `yaml:"group_id"`
+		Topic   string   `yaml:"topic" default:"site_alerts"`
+		GroupID string   `yaml:"group_id" default:"alert-service"`
 	} `yaml:"kafka"`
 
 	Telegram struct {
-		BotToken string `yaml:"bot_token"`
+		BotToken string `yaml:"bot_token" secret:"true"`
 		ChatID   string `yaml:"chat_id"`
 	} `yaml:"telegram"`
 
 	Redis struct {
-		Addr     string `yaml:"addr"`
-		Password string `yaml:"password"`
+		Addr     string `yaml:"addr" default:"localhost:6379"`
+		Password string `yaml:"password" secret:"true"`
 		DB       int    `yaml:"db"`
 	} `yaml:"redis"`
+
+	Postgres struct {
+		DSN string `yaml:"dsn" secret:"true"`
+	} `yaml:"postgres"`
+
+	Escalation struct {
+		Interval int `yaml:"interval" default:"30"`
+	} `yaml:"escalation"`
+
+	// Results keeps every check result for the CRUD API's site status and
+	// history; older ones are pruned.
+	Results struct {
+		RetentionHours int `yaml:"retention_hours" default:"168"`
+	} `yaml:"results"`
+
+	Logging logger.Config  `yaml:"logging"`
+	Tracing tracing.Config `yaml:"tracing"`
 }
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+
+	"site-monitor/pkg/logger"
+	"site-monitor/pkg/tracing"
+)
+
+var promLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type validationErrors []string
+
+func (v *validationErrors) add(field, format string, args ...any) {
+	*v = append(*v, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validationErrors) positive(field string, n int) {
+	if n <= 0 {
+		v.add(field, "must be positive, got %d", n)
+	}
+}
+
+func (v *validationErrors) port(field string, n int) {
+	if n <= 0 || n > 65535 {
+		v.add(field, "must be a port between 1 and 65535, got %d", n)
+	}
+}
+
+func (v *validationErrors) required(field, s string) {
+	if strings.TrimSpace(s) == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *validationErrors) httpURL(field, s string) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, "must be an http(s) URL, got %q", s)
+	}
+}
+
+func (v *validationErrors) hostPorts(field string, addrs []string) {
+	if len(addrs) == 0 {
+		v.add(field, "at least one address is required")
+	}
+	for _, addr := range addrs {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			v.add(field, "invalid address %q, expected host:port", addr)
+		}
+	}
+}
+
+func (v *validationErrors) logging(field string, cfg logger.Config) {
+	if cfg.Format != "json" && cfg.Format != "console" {
+		v.add(field+".format", "must be \"json\" or \"console\", got %q", cfg.Format)
+	}
+	if _, err := zapcore.ParseLevel(cfg.Level); err != nil {
+		v.add(field+".level", "unknown level %q", cfg.Level)
+	}
+	if cfg.Sampling.Initial < 0 || cfg.Sampling.Thereafter < 0 {
+		v.add(field+".sampling", "initial and thereafter must not be negative")
+	}
+}
+
+func (v *validationErrors) tracing(field string, cfg tracing.Config) {
+	switch cfg.Exporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		v.add(field+".exporter", "must be one of none, otlp, stdout, got %q", cfg.Exporter)
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		v.add(field+".sample_ratio", "must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+}
+
+func (v validationErrors) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return errors.New("invalid config:\n  - " + strings.Join(v, "\n  - "))
+}
+
+func (c *CheckerConfig) Validate() error {
+	var v validationErrors
+	v.port("server.port", c.Server.Port)
+	v.positive("checker.timeout", c.Checker.Timeout)
+	v.positive("checker.interval", c.Checker.Interval)
+	v.positive("checker.page_size", c.Checker.PageSize)
+	v.httpURL("checker.api_url", c.Checker.ApiURL)
+	v.hostPorts("kafka.brokers", c.Kafka.Brokers)
+	v.required("kafka.topic", c.Kafka.Topic)
+	switch c.Prometheus.Mode {
+	case MetricsModePush:
+		v.httpURL("prometheus.pushgateway_url", c.Prometheus.PushgatewayURL)
+	case MetricsModePull:
+	default:
+		v.add("prometheus.mode", "must be %q or %q, got %q", MetricsModePush, MetricsModePull, c.Prometheus.Mode)
+	}
+	seen := make(map[string]bool)
+	for _, key := range c.Prometheus.SiteLabels {
+		if !promLabelName.MatchString(key) {
+			v.add("prometheus.site_labels", "%q is not a valid Prometheus label name", key)
+		}
+		if seen[key] {
+			v.add("prometheus.site_labels", "%q is listed more than once", key)
+		}
+		seen[key] = true
+	}
+	for name, m := range c.Probe.Modules {
+		v.probeModule("probe.modules."+name, m)
+	}
+	v.logging("logging", c.Logging)
+	v.tracing("tracing", c.Tracing)
+	return v.err()
+}
+
+func (v *validationErrors) probeModule(field string, m ProbeModule) {
+	if m.Prober != ProberHTTP {
+		v.add(field+".prober", "only the %q prober is supported, got %q", ProberHTTP, m.Prober)
+	}
+	if m.Timeout < 0 {
+		v.add(field+".timeout", "must not be negative, got %d", m.Timeout)
+	}
+	for _, code := range m.HTTP.ValidStatusCodes {
+		if code < 100 || code > 599 {
+			v.add(field+".http.valid_status_codes", "invalid status code %d", code)
+		}
+	}
+	if m.HTTP.FailIfSSL && m.HTTP.FailIfNotSSL {
+		v.add(field+".http", "fail_if_ssl and fail_if_not_ssl are mutually exclusive")
+	}
+}
+
+func (c *CrudConfig) Validate() error {
+	var v validationErrors
+	v.port("server.port", c.Server.Port)
+	v.required("postgres.dsn", c.Postgres.DSN)
+	if jwt := c.Auth.JWT; jwt.Enabled() {
+		if jwt.JWKSURL != "" && jwt.JWKSFile != "" {
+			v.add("auth.jwt", "jwks_url and jwks_file are mutually exclusive")
+		}
+		if jwt.JWKSURL != "" {
+			v.httpURL("auth.jwt.jwks_url", jwt.JWKSURL)
+		}
+		v.required("auth.jwt.role_claim", jwt.RoleClaim)
+		v.required("auth.jwt.org_claim", jwt.OrgClaim)
+		v.positive("auth.jwt.refresh_interval", jwt.RefreshInterval)
+	}
+	if c.Audit.StreamEnabled() {
+		v.hostPorts("audit.kafka.brokers", c.Audit.Kafka.Brokers)
+		v.required("audit.kafka.topic", c.Audit.Kafka.Topic)
+	}
+	if c.Checker.URL != "" {
+		v.httpURL("checker.url", c.Checker.URL)
+		v.positive("checker.timeout", c.Checker.Timeout)
+	}
+	v.logging("logging", c.Logging)
+	v.tracing("tracing", c.Tracing)
+	return v.err()
+}
+
+func (c *AlertConfig) Validate() error {
+	var v validationErrors
+	v.port("server.port", c.Server.Port)
+	v.hostPorts("kafka.brokers", c.Kafka.Brokers)
+	v.required("kafka.topic", c.Kafka.Topic)
+	v.required("kafka.group_id", c.Kafka.GroupID)
+
+	hasChat := c.Telegram.ChatID != "" && c.Telegram.ChatID != "0"
+	if c.Telegram.BotToken != "" && !hasChat {
+		v.add("telegram.chat_id", "is required when telegram.bot_token is set, got %q", c.Telegram.ChatID)
+	}
+	if c.Telegram.BotToken == "" && hasChat {
+		v.add("telegram.bot_token", "is required when telegram.chat_id is set")
+	}
+
+	v.hostPorts("redis.addr", []string{c.Redis.Addr})
+	if c.Redis.DB < 0 {
+		v.add("redis.db", "must not be negative, got %d", c.Redis.DB)
+	}
+	v.required("postgres.dsn", c.Postgres.DSN)
+	v.positive("escalation.interval", c.Escalation.Interval)
+	v.positive("results.retention_hours", c.Results.RetentionHours)
+	v.logging("logging", c.Logging)
+	v.tracing("tracing", c.Tracing)
+	return v.err()
+}
+
+func (c *AlertConfig) TelegramEnabled() bool {
+	return c.Telegram.BotToken != ""
+}
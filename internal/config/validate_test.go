@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadYAML(t *testing.T, yaml string, out Validator) error {
+	t.Helper()
+	t.Setenv("CONFIG_PATH", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path, out)
+}
+
+const validChecker = `
+checker:
+  api_url: "http://crud:8080"
+kafka:
+  brokers: ["kafka:9092"]
+prometheus:
+  mode: "pull"
+`
+
+func TestCheckerConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"valid", validChecker, ""},
+		{"site labels", validChecker + `  site_labels: ["env", "tier"]`, ""},
+		{"duplicate site label", validChecker + `  site_labels: ["env", "env"]`, `"env" is listed more than once`},
+		{"invalid site label", validChecker + `  site_labels: ["env-name"]`, "not a valid Prometheus label name"},
+		{"push without gateway", strings.Replace(validChecker, `"pull"`, `"push"`, 1), "prometheus.pushgateway_url"},
+		{"unknown mode", strings.Replace(validChecker, `"pull"`, `"both"`, 1), "prometheus.mode"},
+		{"no brokers", strings.Replace(validChecker, `["kafka:9092"]`, `[]`, 1), "kafka.brokers"},
+		{"broker without port", strings.Replace(validChecker, `"kafka:9092"`, `"kafka"`, 1), "kafka.brokers"},
+		{"api url not http", strings.Replace(validChecker, `http://crud:8080`, `crud:8080`, 1), "checker.api_url"},
+		{"probe module", validChecker + `
+probe:
+  modules:
+    body:
+      prober: "http"
+      http:
+        valid_status_codes: [200, 204]
+        fail_if_body_not_matches_regexp: ["ok$"]`, ""},
+		{"probe prober", validChecker + `
+probe:
+  modules:
+    icmp:
+      prober: "icmp"`, `only the "http" prober is supported`},
+		{"probe status code", validChecker + `
+probe:
+  modules:
+    bad:
+      prober: "http"
+      http:
+        valid_status_codes: [700]`, "invalid status code 700"},
+		{"probe ssl flags", validChecker + `
+probe:
+  modules:
+    bad:
+      prober: "http"
+      http:
+        fail_if_ssl: true
+        fail_if_not_ssl: true`, "mutually exclusive"},
+		{"probe regexp", validChecker + `
+probe:
+  modules:
+    bad:
+      prober: "http"
+      http:
+        fail_if_body_matches_regexp: ["(unclosed"]`, `invalid regexp "(unclosed"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg CheckerConfig
+			err := loadYAML(t, tt.yaml, &cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbeRegexpCompiledOnLoad(t *testing.T) {
+	var cfg CheckerConfig
+	err := loadYAML(t, validChecker+`
+probe:
+  modules:
+    body:
+      prober: "http"
+      http:
+        fail_if_body_matches_regexp: ["error", "^5\\d\\d"]`, &cfg)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	res := cfg.Probe.Modules["body"].HTTP.FailIfBodyMatchesRegexp
+	if len(res) != 2 || res[0].Regexp == nil || res[1].Regexp == nil {
+		t.Fatalf("regexps = %v, want two compiled patterns", res)
+	}
+	if !res[1].MatchString("503 Service Unavailable") || res[1].MatchString("200 OK") {
+		t.Errorf("%q matches the wrong bodies", res[1].String())
+	}
+}
+
+func TestCrudConfigAuthDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want bool
+	}{
+		{"no auth block", `postgres: {dsn: "postgres://db/x"}`, true},
+		{"empty auth block", "postgres: {dsn: \"postgres://db/x\"}\nauth: {}", true},
+		{"explicitly disabled", "postgres: {dsn: \"postgres://db/x\"}\nauth: {enabled: false}", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg CrudConfig
+			if err := loadYAML(t, tt.yaml, &cfg); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Auth.Enabled != tt.want {
+				t.Errorf("auth.enabled = %t, want %t", cfg.Auth.Enabled, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrudConfigValidate(t *testing.T) {
+	const dsn = "postgres: {dsn: \"postgres://db/x\"}\n"
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"no dsn", "server: {port: 8080}", "postgres.dsn"},
+		{"jwks url and file", dsn + "auth: {jwt: {jwks_url: \"https://idp/jwks\", jwks_file: \"/jwks.json\"}}",
+			"mutually exclusive"},
+		{"jwks url not http", dsn + "auth: {jwt: {jwks_url: \"idp/jwks\"}}", "auth.jwt.jwks_url"},
+		{"empty org claim", dsn + "auth: {jwt: {jwks_file: \"/jwks.json\", org_claim: \" \"}}", "auth.jwt.org_claim"},
+		{"checker url", dsn + "checker: {url: \"checker:9101\"}", "checker.url"},
+		{"audit stream without topic", dsn + "audit: {kafka: {brokers: [\"kafka:9092\"], topic: \" \"}}", "audit.kafka.topic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg CrudConfig
+			err := loadYAML(t, tt.yaml, &cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
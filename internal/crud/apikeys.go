@@ -0,0 +1,88 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"site-monitor/internal/auth"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+type apiKeyRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// The plain key is only ever shown on creation.
+type apiKeyResponse struct {
+	storage.APIKey
+	Key string `json:"key"`
+}
+
+func (h *Handler) registerAPIKeyRoutes(r chi.Router) {
+	r.Get("/api-keys", h.handleGetAPIKeys)
+	r.Post("/api-keys", h.handleAddAPIKey)
+	r.Delete("/api-keys/{id}", h.handleRevokeAPIKey)
+}
+
+func (h *Handler) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.storage.GetAPIKeys(r.Context(), orgFrom(r))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get API keys")
+		return
+	}
+	utils.WriteJSON(h.log, w, keys, http.StatusOK)
+}
+
+func (h *Handler) handleAddAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req apiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddAPIKey", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Name == "" {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "name is required")
+		return
+	}
+	if !auth.ValidRole(req.Role) {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "role must be viewer, editor, admin or service")
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		h.writeError(w, r, err, "Failed to generate API key")
+		return
+	}
+
+	// Platform-wide admins without X-Org-ID create platform-wide keys.
+	k := storage.APIKey{Name: req.Name, Prefix: prefix, Hash: hash, Role: req.Role}
+	if orgID := orgFrom(r); orgID != storage.AllOrgs {
+		k.OrgID = orgID
+	}
+	k.ID, err = h.storage.AddAPIKey(r.Context(), orgFrom(r), k)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add API key", "name", req.Name)
+		return
+	}
+
+	h.log.Ctx(r.Context()).Infow("API key created", "id", k.ID, "name", k.Name, "role", k.Role, "org_id", k.OrgID)
+	utils.WriteJSON(h.log, w, apiKeyResponse{APIKey: k, Key: key}, http.StatusCreated)
+}
+
+func (h *Handler) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := h.storage.RevokeAPIKey(r.Context(), orgFrom(r), id); err != nil {
+		h.writeError(w, r, err, "Failed to revoke API key", "id", id)
+		return
+	}
+	h.log.Ctx(r.Context()).Infow("API key revoked", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,143 @@
+package crud
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"site-monitor/internal/audit"
+	"site-monitor/internal/auth"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/logger"
+	"site-monitor/pkg/utils"
+)
+
+// secretChannelSettings are shown as a fingerprint, so rotations stay visible.
+var secretChannelSettings = []string{"bot_token", "webhook_url", "url"}
+
+func (h *Handler) registerAuditRoutes(r chi.Router) {
+	r.Get("/audit", h.handleGetAudit)
+}
+
+func (h *Handler) handleGetAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := storage.AuditFilter{
+		Actor:        q.Get("actor"),
+		Action:       q.Get("action"),
+		ResourceType: q.Get("resource_type"),
+		ResourceID:   q.Get("resource_id"),
+	}
+
+	for name, dst := range map[string]**time.Time{"since": &f.Since, "until": &f.Until} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.WriteProblem(w, r, http.StatusBadRequest, name+" must be an RFC 3339 timestamp")
+			return
+		}
+		*dst = &t
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			utils.WriteProblem(w, r, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		f.Limit = limit
+	}
+
+	entries, err := h.storage.GetAuditEntries(r.Context(), orgFrom(r), f)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get audit entries")
+		return
+	}
+	if entries == nil {
+		entries = []storage.AuditEntry{}
+	}
+	utils.WriteJSON(h.log, w, entries, http.StatusOK)
+}
+
+func (h *Handler) record(r *http.Request, orgID, action, resourceType, resourceID string, before, after any) {
+	p, _ := auth.PrincipalFrom(r.Context())
+	h.audit.Record(r.Context(), audit.Event{
+		OrgID:        orgID,
+		Actor:        p.Method + ":" + p.Subject,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		RequestID:    logger.RequestID(r.Context()),
+		SourceIP:     sourceIP(r),
+		Before:       before,
+		After:        after,
+	})
+}
+
+func forChange[T any](h *Handler, w http.ResponseWriter, r *http.Request, id string,
+	get func(ctx context.Context, orgID, id string) (*T, error)) (obj *T, ok bool) {
+	obj, err := get(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to load object before change", "id", id)
+		return nil, false
+	}
+	if obj == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return nil, false
+	}
+	return obj, true
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func redactChannel(c *storage.NotificationChannel) *storage.NotificationChannel {
+	if c == nil {
+		return nil
+	}
+	redacted := *c
+	redacted.Settings = make(map[string]string, len(c.Settings))
+	for k, v := range c.Settings {
+		redacted.Settings[k] = v
+	}
+	for _, k := range secretChannelSettings {
+		if v := redacted.Settings[k]; v != "" {
+			redacted.Settings[k] = fingerprint(v)
+		}
+	}
+	return &redacted
+}
+
+func redactChannels(cs []storage.NotificationChannel) []storage.NotificationChannel {
+	redacted := make([]storage.NotificationChannel, len(cs))
+	for i := range cs {
+		redacted[i] = *redactChannel(&cs[i])
+	}
+	return redacted
+}
+
+// keepSecrets restores secrets that an update sends back in redacted form.
+func keepSecrets(c, before *storage.NotificationChannel) {
+	for _, k := range secretChannelSettings {
+		if v := before.Settings[k]; v != "" && c.Settings[k] == fingerprint(v) {
+			c.Settings[k] = v
+		}
+	}
+}
+
+func fingerprint(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return "redacted:" + hex.EncodeToString(sum[:4])
+}
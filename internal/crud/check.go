@@ -0,0 +1,94 @@
+package crud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/tracing"
+	"site-monitor/pkg/utils"
+)
+
+type CheckerClient struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+func NewCheckerClient(url, token string, timeout time.Duration) *CheckerClient {
+	return &CheckerClient{url: strings.TrimRight(url, "/"), token: token, http: &http.Client{Timeout: timeout}}
+}
+
+type checkerResult struct {
+	SiteID       string    `json:"site_id"`
+	OrgID        string    `json:"org_id"`
+	URL          string    `json:"url"`
+	StatusCode   int       `json:"status"`
+	ResponseTime int       `json:"response_time_ms"`
+	Timestamp    time.Time `json:"timestamp"`
+	ErrorMsg     string    `json:"error"`
+	Maintenance  bool      `json:"maintenance"`
+}
+
+// Check also publishes the result for alerting. Up follows the alert
+// service's rule.
+func (c *CheckerClient) Check(ctx context.Context, siteID string) (*storage.CheckResult, error) {
+	body, err := json.Marshal(map[string]string{"site_id": siteID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/check", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	tracing.InjectHTTP(ctx, req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("checker answered %s", resp.Status)
+	}
+
+	var res checkerResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("decode checker result: %w", err)
+	}
+	return &storage.CheckResult{
+		SiteID: res.SiteID, OrgID: res.OrgID, URL: res.URL, Status: res.StatusCode, Up: res.StatusCode == http.StatusOK,
+		ResponseTimeMs: res.ResponseTime, Error: res.ErrorMsg, Maintenance: res.Maintenance, CheckedAt: res.Timestamp,
+	}, nil
+}
+
+func (h *Handler) handleCheckSite(w http.ResponseWriter, r *http.Request) {
+	if h.checker == nil {
+		utils.WriteProblem(w, r, http.StatusServiceUnavailable, "on-demand checks are off, set checker.url and checker.token in the CRUD service config")
+		return
+	}
+	site, ok := h.siteParam(w, r)
+	if !ok {
+		return
+	}
+	if !site.Active {
+		utils.WriteProblem(w, r, http.StatusConflict, "site is paused, resume it to check it")
+		return
+	}
+
+	result, err := h.checker.Check(r.Context(), site.ID)
+	if err != nil {
+		h.log.Ctx(r.Context()).Errorw("On-demand check failed", "id", site.ID, "url", site.URL, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadGateway, "the checker service didn't answer")
+		return
+	}
+	h.log.Ctx(r.Context()).Infow("Site checked on demand", "id", site.ID, "url", site.URL, "status", result.Status)
+	utils.WriteJSON(h.log, w, result, http.StatusOK)
+}
@@ -0,0 +1,45 @@
+package crud
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"site-monitor/pkg/utils"
+)
+
+// invalidRequest marks client errors returned from a storage callback.
+type invalidRequest struct{ error }
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", etag(version))
+}
+
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatch returns the version the update must still find, or 0 when the
+// request is unconditional. It writes the 412 itself.
+func ifMatch(w http.ResponseWriter, r *http.Request, current int64) (version int64, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == etag(current) {
+			return current, true
+		}
+	}
+	utils.WriteProblem(w, r, http.StatusPreconditionFailed, "site has changed, fetch it again and retry with the new ETag")
+	return 0, false
+}
+
+func mediaType(r *http.Request) string {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mt
+}
@@ -0,0 +1,99 @@
+package crud
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"site-monitor/internal/storage"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		current int64
+		want    int64
+		ok      bool
+	}{
+		{"no header", "", 3, 0, true},
+		{"wildcard", "*", 3, 0, true},
+		{"current tag", `"3"`, 3, 3, true},
+		{"stale tag", `"2"`, 3, 0, false},
+		{"one of several", `"1", "2", "3"`, 3, 3, true},
+		{"several without spaces", `"2","3"`, 3, 3, true},
+		{"none of several", `"1", "2"`, 3, 0, false},
+		{"weak tag", `W/"3"`, 3, 0, false},
+		{"unquoted", `3`, 3, 0, false},
+		{"padding", `  "3"  `, 3, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/sites/x", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			version, ok := ifMatch(w, r, tt.current)
+			if version != tt.want || ok != tt.ok {
+				t.Errorf("ifMatch(%q, %d) = %d, %t, want %d, %t", tt.header, tt.current, version, ok, tt.want, tt.ok)
+			}
+			if !ok && w.Code != http.StatusPreconditionFailed {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+			}
+			if ok && w.Body.Len() > 0 {
+				t.Errorf("unexpected response body %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestApplySitePatch(t *testing.T) {
+	base := storage.Site{
+		ID: "id", OrgID: "org", URL: "https://a.example", Active: true, Team: "web",
+		Labels: map[string]string{"env": "prod"}, Version: 3,
+	}
+	tests := []struct {
+		name    string
+		patch   string
+		wantErr bool
+		check   func(s storage.Site) bool
+	}{
+		{"pause", `{"active":false}`, false, func(s storage.Site) bool { return !s.Active && s.URL == base.URL }},
+		{"reset team", `{"team":null}`, false, func(s storage.Site) bool { return s.Team == "" && s.Active }},
+		{"drop label", `{"labels":{"env":null}}`, false, func(s storage.Site) bool { return len(s.Labels) == 0 }},
+		{"id and version are kept", `{"id":"other","version":9,"org_id":null}`, false,
+			func(s storage.Site) bool { return s.ID == "id" && s.OrgID == "org" && s.Version == 3 }},
+		{"null active", `{"active":null}`, true, nil},
+		{"null url", `{"url":null}`, true, nil},
+		{"null url with spaces", `{"url" : null }`, true, nil},
+		{"empty url", `{"url":""}`, true, nil},
+		{"not an object", `"x"`, true, nil},
+		{"invalid json", `{"active":`, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := base
+			s.Labels = map[string]string{"env": "prod"}
+			err := applySitePatch(&s, []byte(tt.patch))
+			if tt.wantErr {
+				var invalid invalidRequest
+				if !errors.As(err, &invalid) {
+					t.Fatalf("applySitePatch(%s) = %v, want an invalidRequest", tt.patch, err)
+				}
+				if !s.Active || s.URL != base.URL {
+					t.Errorf("site changed on error: %+v", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applySitePatch(%s): %v", tt.patch, err)
+			}
+			if !tt.check(s) {
+				t.Errorf("applySitePatch(%s) = %+v", tt.patch, s)
+			}
+		})
+	}
+}
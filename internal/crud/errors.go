@@ -0,0 +1,46 @@
+package crud
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+// writeError logs unexpected errors and hides them behind a bare 500.
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error, msg string, kv ...any) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+	case errors.Is(err, storage.ErrDuplicate):
+		utils.WriteProblem(w, r, http.StatusConflict, "conflicts with an existing resource")
+	case errors.Is(err, storage.ErrVersionConflict):
+		utils.WriteProblem(w, r, http.StatusPreconditionFailed, "resource has changed, fetch it again and retry with the new ETag")
+	case errors.Is(err, storage.ErrOrgRequired):
+		utils.WriteProblem(w, r, http.StatusBadRequest, "pick an organization with the "+orgHeader+" header")
+	default:
+		h.log.Ctx(r.Context()).Errorw(msg, append(kv, "error", err)...)
+		utils.WriteProblem(w, r, http.StatusInternalServerError, "")
+	}
+}
+
+func uuidParam(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	v := chi.URLParam(r, name)
+	if _, err := uuid.Parse(v); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, name+" must be a UUID")
+		return "", false
+	}
+	return v, true
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	utils.WriteProblem(w, r, http.StatusNotFound, "no such route")
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	utils.WriteProblem(w, r, http.StatusMethodNotAllowed, r.Method+" is not supported here")
+}
@@ -2,121 +2,377 @@ package crud
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 
+	"site-monitor/internal/audit"
+	"site-monitor/internal/auth"
+	"site-monitor/internal/sites"
 	"site-monitor/internal/storage"
+	"site-monitor/pkg/health"
 	"site-monitor/pkg/logger"
+	"site-monitor/pkg/utils"
 )
 
 type Handler struct {
 	storage storage.Storage
+	auth    *auth.Authenticator
+	audit   *audit.Recorder
+	checker *CheckerClient
 	log     *logger.Logger
 }
 
-func NewHandler(storage storage.Storage, log *logger.Logger) *Handler {
-	return &Handler{storage: storage, log: log}
+func NewHandler(storage storage.Storage, authn *auth.Authenticator, recorder *audit.Recorder, checker *CheckerClient,
+	log *logger.Logger) *Handler {
+	return &Handler{storage: storage, auth: authn, audit: recorder, checker: checker, log: log}
 }
 
 func (h *Handler) RegisterRoutes(r chi.Router) {
-	r.Get("/sites", h.handleGetSites)
-	r.Get("/sites/{id}", h.handleGetSiteByID)
-	r.Post("/sites", h.handleAddSite)
-	r.Put("/sites/{id}", h.handleUpdateSite)
-	r.Delete("/sites/{id}", h.handleDeleteSite)
-
-	r.Get("/health", func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok"}`))
+	readiness := health.Readiness(h.log, health.DefaultTimeout, map[string]health.Check{
+		"postgres": h.storage.Ping,
 	})
+	r.Handle("/livez", health.Liveness(h.log))
+	r.Handle("/readyz", readiness)
+	r.Handle("/health", readiness)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.auth.Middleware)
+		r.Use(h.resolveOrg)
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.Require(auth.RoleAdmin), auth.RequireGlobal)
+			h.registerOrgRoutes(r)
+			r.Handle("/admin/log-level", h.log.LevelHandler())
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.Require(auth.RoleAdmin))
+			h.registerAPIKeyRoutes(r)
+			h.registerAuditRoutes(r)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.Require(auth.RoleViewer))
+			r.Get("/whoami", h.handleWhoAmI)
+			r.Get("/maintenance/ended", h.handleGetEndedMaintenanceWindows)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireService)
+			h.registerMaintenanceStateRoutes(r)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireByMethod)
+
+			r.Get("/sites", h.handleGetSites)
+			r.Get("/sites/{id}", h.handleGetSiteByID)
+			r.Post("/sites", h.handleAddSite)
+			r.Post("/sites/import", h.handleImportSites)
+			r.Get("/sites/export", h.handleExportSites)
+			r.Put("/sites/{id}", h.handleUpdateSite)
+			r.Patch("/sites/{id}", h.handlePatchSite)
+			r.Delete("/sites/{id}", h.handleDeleteSite)
+			r.Get("/sites/{id}/status", h.handleGetSiteStatus)
+			r.Get("/sites/{id}/results", h.handleGetSiteResults)
+			r.Post("/sites/{id}/check", h.handleCheckSite)
+
+			h.registerMaintenanceRoutes(r)
+			h.registerSilenceRoutes(r)
+			h.registerOnCallRoutes(r)
+			h.registerRoutingRoutes(r)
+		})
+	})
+
+	r.NotFound(notFound)
+	r.MethodNotAllowed(methodNotAllowed)
 }
 
 func (h *Handler) handleGetSites(w http.ResponseWriter, r *http.Request) {
-	sites, err := h.storage.GetSites(r.Context())
+	f, err := siteFilter(r)
 	if err != nil {
-		h.log.Sugar.Errorw("Failed to get sites", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
-	h.log.Sugar.Infow("Fetched all sites", "count", len(sites))
-	writeJSON(h.log, w, sites, http.StatusOK)
+
+	page, err := h.storage.GetSites(r.Context(), orgFrom(r), f)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "cursor is invalid or was issued for another sort")
+		return
+	}
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get sites")
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		next := *r.URL
+		q := next.Query()
+		q.Set("cursor", page.NextCursor)
+		next.RawQuery = q.Encode()
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+		w.Header().Set("Link", "<"+next.RequestURI()+`>; rel="next"`)
+	}
+	h.log.Ctx(r.Context()).Infow("Fetched sites", "count", len(page.Sites), "total", page.Total)
+	utils.WriteJSON(h.log, w, page.Sites, http.StatusOK)
+}
+
+func siteFilter(r *http.Request) (storage.SiteFilter, error) {
+	q := r.URL.Query()
+	f := storage.SiteFilter{
+		Tags:        q["tag"],
+		CheckType:   q.Get("check_type"),
+		URLContains: q.Get("url_contains"),
+		ExternalID:  q.Get("external_id"),
+		Cursor:      q.Get("cursor"),
+	}
+
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("active must be true or false")
+		}
+		f.Active = &active
+	}
+	for _, l := range q["label"] {
+		k, v, hasValue := strings.Cut(l, "=")
+		if k == "" {
+			return f, errors.New("label must be key=value or key")
+		}
+		if !hasValue {
+			f.LabelKeys = append(f.LabelKeys, k)
+			continue
+		}
+		if f.Labels == nil {
+			f.Labels = map[string]string{}
+		}
+		f.Labels[k] = v
+	}
+	if f.CheckType != "" && f.CheckType != "http" && f.CheckType != "https" {
+		return f, errors.New("check_type must be http or https")
+	}
+	if v := q.Get("sort"); v != "" {
+		f.Sort, f.Desc = strings.TrimPrefix(v, "-"), strings.HasPrefix(v, "-")
+		if !slices.Contains(storage.SiteSortFields, f.Sort) {
+			return f, fmt.Errorf("sort must be one of %s, with - for descending", strings.Join(storage.SiteSortFields, ", "))
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return f, errors.New("limit must be a positive integer")
+		}
+		f.Limit = limit
+	}
+	return f, nil
 }
 
 func (h *Handler) handleGetSiteByID(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	site, err := h.storage.GetSiteByID(r.Context(), id)
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	site, err := h.storage.GetSiteByID(r.Context(), orgFrom(r), id)
 	if err != nil {
-		h.log.Sugar.Errorw("Failed to get site by ID", "id", id, "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, err, "Failed to get site by ID", "id", id)
 		return
 	}
 	if site == nil {
-		h.log.Sugar.Warnw("Site not found", "id", id)
-		http.Error(w, "not found", http.StatusNotFound)
+		h.log.Ctx(r.Context()).Warnw("Site not found", "id", id)
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
 		return
 	}
-	h.log.Sugar.Infow("Fetched site by ID", "id", id)
-	writeJSON(h.log, w, site, http.StatusOK)
+	h.log.Ctx(r.Context()).Infow("Fetched site by ID", "id", id)
+	setETag(w, site.Version)
+	utils.WriteJSON(h.log, w, site, http.StatusOK)
 }
 
 func (h *Handler) handleAddSite(w http.ResponseWriter, r *http.Request) {
 	var site storage.Site
 	if err := json.NewDecoder(r.Body).Decode(&site); err != nil {
-		h.log.Sugar.Warnw("Invalid request body for AddSite", "error", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddSite", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	id, err := h.storage.AddSite(r.Context(), site)
+	sites.Normalize(&site)
+	if err := sites.Validate(site); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	site.OrgID = ownerOrg(r)
+	id, err := h.storage.AddSite(r.Context(), site.OrgID, site)
 	if err != nil {
-		h.log.Sugar.Errorw("Failed to add site", "url", site.URL, "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, err, "Failed to add site", "url", site.URL)
 		return
 	}
 
 	site.ID = id
-	h.log.Sugar.Infow("Site added", "id", id, "url", site.URL)
-	writeJSON(h.log, w, site, http.StatusCreated)
+	h.record(r, site.OrgID, audit.ActionCreate, audit.ResourceSite, id, nil, site)
+	h.log.Ctx(r.Context()).Infow("Site added", "id", id, "url", site.URL)
+	utils.WriteJSON(h.log, w, site, http.StatusCreated)
+}
+
+// Active has no safe default for a full replacement, so PUT requires it.
+type siteReplacement struct {
+	storage.Site
+	Active *bool `json:"active"`
 }
 
 func (h *Handler) handleUpdateSite(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
 
-	var site storage.Site
-	if err := json.NewDecoder(r.Body).Decode(&site); err != nil {
-		h.log.Sugar.Warnw("Invalid request body for UpdateSite", "id", id, "error", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	var req siteReplacement
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for UpdateSite", "id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Active == nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "active is required, use PATCH to change single fields")
+		return
+	}
+	site := req.Site
+	site.Active = *req.Active
+
+	sites.Normalize(&site)
+	if err := sites.Validate(site); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 	site.ID = id
 
-	if err := h.storage.UpdateSite(r.Context(), site); err != nil {
-		h.log.Sugar.Errorw("Failed to update site", "id", id, "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	before, ok := forChange(h, w, r, id, h.storage.GetSiteByID)
+	if !ok {
+		return
+	}
+	site.OrgID = before.OrgID
+	if site.Version, ok = ifMatch(w, r, before.Version); !ok {
 		return
 	}
 
-	h.log.Sugar.Infow("Site updated", "id", id, "url", site.URL, "active", site.Active)
-	writeJSON(h.log, w, site, http.StatusOK)
+	updated, err := h.storage.UpdateSite(r.Context(), site.OrgID, site)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to update site", "id", id)
+		return
+	}
+	h.record(r, updated.OrgID, audit.ActionUpdate, audit.ResourceSite, id, before, updated)
+
+	h.log.Ctx(r.Context()).Infow("Site updated", "id", id, "url", updated.URL, "active", updated.Active, "version", updated.Version)
+	setETag(w, updated.Version)
+	utils.WriteJSON(h.log, w, updated, http.StatusOK)
 }
 
-func (h *Handler) handleDeleteSite(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	if err := h.storage.DeleteSite(r.Context(), id); err != nil {
-		h.log.Sugar.Errorw("Failed to delete site", "id", id, "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+func (h *Handler) handlePatchSite(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	if ct := mediaType(r); ct != utils.MergePatchContentType && ct != "application/json" {
+		utils.WriteProblem(w, r, http.StatusUnsupportedMediaType, "use "+utils.MergePatchContentType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(patch) {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	h.log.Sugar.Infow("Site deleted", "id", id)
-	w.WriteHeader(http.StatusNoContent)
+	before, ok := forChange(h, w, r, id, h.storage.GetSiteByID)
+	if !ok {
+		return
+	}
+	version, ok := ifMatch(w, r, before.Version)
+	if !ok {
+		return
+	}
+
+	updated, err := h.storage.PatchSite(r.Context(), before.OrgID, id, version, func(s *storage.Site) error {
+		// Audit the locked row, which may be newer than the one read above.
+		*before = *s
+		return applySitePatch(s, patch)
+	})
+	var invalid invalidRequest
+	if errors.As(err, &invalid) {
+		utils.WriteProblem(w, r, http.StatusBadRequest, invalid.Error())
+		return
+	}
+	if err != nil {
+		h.writeError(w, r, err, "Failed to patch site", "id", id)
+		return
+	}
+	h.record(r, updated.OrgID, audit.ActionUpdate, audit.ResourceSite, id, before, updated)
+
+	h.log.Ctx(r.Context()).Infow("Site patched", "id", id, "url", updated.URL, "active", updated.Active, "version", updated.Version)
+	setETag(w, updated.Version)
+	utils.WriteJSON(h.log, w, updated, http.StatusOK)
 }
 
-func writeJSON(log *logger.Logger, w http.ResponseWriter, v any, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(v); err != nil {
-		log.Sugar.Errorw("Failed to write JSON response", "error", err)
+// A merge patch null removes the member, which for these would silently
+// mean an empty URL or a paused site.
+var nonNullSiteFields = []string{"url", "active"}
+
+func applySitePatch(s *storage.Site, patch []byte) error {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(patch, &fields) == nil {
+		for _, k := range nonNullSiteFields {
+			if v, ok := fields[k]; ok && string(v) == "null" {
+				return invalidRequest{fmt.Errorf("%s cannot be null", k)}
+			}
+		}
+	}
+
+	doc, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	merged, err := utils.MergePatch(doc, patch)
+	if err != nil {
+		return invalidRequest{err}
+	}
+
+	var patched storage.Site
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return invalidRequest{errors.New("patch does not describe a site")}
+	}
+	patched.ID, patched.OrgID, patched.Version = s.ID, s.OrgID, s.Version
+
+	sites.Normalize(&patched)
+	if err := sites.Validate(patched); err != nil {
+		return invalidRequest{err}
+	}
+	*s = patched
+	return nil
+}
+
+func (h *Handler) handleDeleteSite(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	before, ok := forChange(h, w, r, id, h.storage.GetSiteByID)
+	if !ok {
+		return
 	}
+
+	if err := h.storage.DeleteSite(r.Context(), before.OrgID, id); err != nil {
+		h.writeError(w, r, err, "Failed to delete site", "id", id)
+		return
+	}
+	h.record(r, before.OrgID, audit.ActionDelete, audit.ResourceSite, id, before, nil)
+
+	h.log.Ctx(r.Context()).Infow("Site deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
 }
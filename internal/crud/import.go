@@ -0,0 +1,205 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"site-monitor/internal/audit"
+	"site-monitor/internal/sites"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+const (
+	maxImportBytes = 10 << 20
+	exportPageSize = 1000
+)
+
+type importRow struct {
+	Row    int    `json:"row"`
+	URL    string `json:"url,omitempty"`
+	Action string `json:"action,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type importReport struct {
+	DryRun  bool        `json:"dry_run"`
+	Applied bool        `json:"applied"`
+	Created int         `json:"created"`
+	Updated int         `json:"updated"`
+	Failed  int         `json:"failed"`
+	Rows    []importRow `json:"rows"`
+}
+
+type importProblem struct {
+	utils.Problem
+	importReport
+}
+
+func (h *Handler) handleImportSites(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = sites.FormatOf(mediaType(r))
+	}
+	if !slices.Contains(sites.Formats, format) {
+		utils.WriteProblem(w, r, http.StatusUnsupportedMediaType,
+			"send text/csv, application/yaml or application/json, or set ?format=csv|yaml|json")
+		return
+	}
+	var opts storage.ImportOptions
+	for name, dst := range map[string]*bool{"dry_run": &opts.DryRun, "update": &opts.Update} {
+		if v := q.Get(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				utils.WriteProblem(w, r, http.StatusBadRequest, name+" must be true or false")
+				return
+			}
+			*dst = b
+		}
+	}
+
+	rows, err := sites.Decode(format, http.MaxBytesReader(w, r.Body, maxImportBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		utils.WriteProblem(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("imports are limited to %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid "+format+": "+err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "no sites to import")
+		return
+	}
+
+	report := importReport{DryRun: opts.DryRun, Rows: make([]importRow, len(rows))}
+	seen := make(map[string]int, len(rows))
+	seenExternal := make(map[string]int)
+	batch := make([]storage.Site, len(rows))
+	for i, row := range rows {
+		sites.Normalize(&row.Site)
+		report.Rows[i] = importRow{Row: row.Row, URL: row.Site.URL}
+		if row.Err == nil {
+			row.Err = sites.Validate(row.Site)
+		}
+		if first, ok := seen[row.Site.URL]; ok && row.Err == nil {
+			row.Err = fmt.Errorf("same url as row %d", first)
+		}
+		if first, ok := seenExternal[row.Site.ExternalID]; ok && row.Err == nil {
+			row.Err = fmt.Errorf("same external_id as row %d", first)
+		}
+		seen[row.Site.URL] = row.Row
+		if row.Site.ExternalID != "" {
+			seenExternal[row.Site.ExternalID] = row.Row
+		}
+		if row.Err != nil {
+			report.Rows[i].Error = strings.ReplaceAll(row.Err.Error(), "\n", "; ")
+			report.Failed++
+		}
+		batch[i] = row.Site
+	}
+	if report.Failed > 0 {
+		h.writeImportProblem(w, r, report)
+		return
+	}
+
+	orgID := ownerOrg(r)
+	results, err := h.storage.ImportSites(r.Context(), orgID, batch, opts)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to import sites", "count", len(batch))
+		return
+	}
+	for i, res := range results {
+		switch {
+		case errors.Is(res.Err, storage.ErrDuplicate) && res.Action == "":
+			report.Rows[i].Error = "a site with this url already exists, import with ?update=true to overwrite it"
+			report.Failed++
+			continue
+		case errors.Is(res.Err, storage.ErrDuplicate):
+			report.Rows[i].Error = "the url or external_id is already used by another site"
+			report.Failed++
+			continue
+		case res.Err != nil:
+			report.Rows[i].Error = res.Err.Error()
+			report.Failed++
+		case res.Action == storage.ImportCreate:
+			report.Created++
+		default:
+			report.Updated++
+		}
+		report.Rows[i].Action = res.Action
+		report.Rows[i].ID = res.Site.ID
+	}
+	if report.Failed > 0 {
+		h.writeImportProblem(w, r, report)
+		return
+	}
+
+	if !opts.DryRun {
+		report.Applied = true
+		for _, res := range results {
+			action := audit.ActionCreate
+			if res.Action == storage.ImportUpdate {
+				action = audit.ActionUpdate
+			}
+			h.record(r, orgID, action, audit.ResourceSite, res.Site.ID, res.Before, res.Site)
+		}
+	}
+	h.log.Ctx(r.Context()).Infow("Sites imported",
+		"format", format, "dry_run", opts.DryRun, "created", report.Created, "updated", report.Updated)
+	utils.WriteJSON(h.log, w, report, http.StatusOK)
+}
+
+func (h *Handler) writeImportProblem(w http.ResponseWriter, r *http.Request, report importReport) {
+	detail := fmt.Sprintf("%d of %d rows can't be imported, nothing was written", report.Failed, len(report.Rows))
+	utils.WriteProblemBody(w, http.StatusUnprocessableEntity, importProblem{
+		Problem:      utils.NewProblem(r, http.StatusUnprocessableEntity, detail),
+		importReport: report,
+	})
+}
+
+func (h *Handler) handleExportSites(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = sites.FormatJSON
+	}
+	if !slices.Contains(sites.Formats, format) {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "format must be csv, yaml or json")
+		return
+	}
+	f, err := siteFilter(r)
+	if err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.Cursor, f.Limit = "", exportPageSize
+
+	var all []storage.Site
+	for {
+		page, err := h.storage.GetSites(r.Context(), orgFrom(r), f)
+		if err != nil {
+			h.writeError(w, r, err, "Failed to export sites")
+			return
+		}
+		all = append(all, page.Sites...)
+		if page.NextCursor == "" {
+			break
+		}
+		f.Cursor = page.NextCursor
+	}
+
+	w.Header().Set("Content-Type", sites.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="sites.`+format+`"`)
+	if err := sites.Encode(format, w, all); err != nil {
+		h.log.Ctx(r.Context()).Errorw("Failed to write sites export", "format", format, "error", err)
+		return
+	}
+	h.log.Ctx(r.Context()).Infow("Sites exported", "format", format, "count", len(all))
+}
@@ -0,0 +1,229 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"site-monitor/internal/audit"
+	"site-monitor/internal/maintenance"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+func (h *Handler) registerMaintenanceRoutes(r chi.Router) {
+	r.Get("/maintenance", h.handleGetMaintenanceWindows)
+	r.Get("/maintenance/active", h.handleGetActiveMaintenanceWindows)
+	r.Get("/maintenance/{id}", h.handleGetMaintenanceWindowByID)
+	r.Post("/maintenance", h.handleAddMaintenanceWindow)
+	r.Put("/maintenance/{id}", h.handleUpdateMaintenanceWindow)
+	r.Delete("/maintenance/{id}", h.handleDeleteMaintenanceWindow)
+}
+
+// The checker's service key records here which windows it applied and summarized.
+func (h *Handler) registerMaintenanceStateRoutes(r chi.Router) {
+	r.Post("/maintenance/seen", h.handleMarkMaintenanceWindowsActive)
+	r.Post("/maintenance/{id}/summary", h.handleMarkMaintenanceSummarySent)
+}
+
+func (h *Handler) handleGetMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := h.storage.GetMaintenanceWindows(r.Context(), orgFrom(r))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get maintenance windows")
+		return
+	}
+	utils.WriteJSON(h.log, w, windows, http.StatusOK)
+}
+
+func (h *Handler) handleGetActiveMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := h.storage.GetMaintenanceWindows(r.Context(), orgFrom(r))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get maintenance windows")
+		return
+	}
+
+	utils.WriteJSON(h.log, w, h.windowsByState(r, windows, true), http.StatusOK)
+}
+
+// Windows with a broken schedule are in neither list.
+func (h *Handler) windowsByState(r *http.Request, windows []storage.MaintenanceWindow, active bool) []storage.MaintenanceWindow {
+	now := time.Now()
+	out := []storage.MaintenanceWindow{}
+	for _, mw := range windows {
+		ok, err := maintenance.IsActive(mw, now)
+		if err != nil {
+			h.log.Ctx(r.Context()).Warnw("Invalid maintenance window schedule", "id", mw.ID, "error", err)
+			continue
+		}
+		if ok == active {
+			out = append(out, mw)
+		}
+	}
+	return out
+}
+
+type seenWindows struct {
+	WindowIDs []string `json:"window_ids"`
+}
+
+func (h *Handler) handleMarkMaintenanceWindowsActive(w http.ResponseWriter, r *http.Request) {
+	var body seenWindows
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	for _, id := range body.WindowIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			utils.WriteProblem(w, r, http.StatusBadRequest, "window_ids must be UUIDs")
+			return
+		}
+	}
+
+	if len(body.WindowIDs) > 0 {
+		now := time.Now()
+		started, err := h.storage.MarkMaintenanceWindowsActive(r.Context(), orgFrom(r), body.WindowIDs, now)
+		if err != nil {
+			h.writeError(w, r, err, "Failed to mark maintenance windows active")
+			return
+		}
+		for _, before := range started {
+			after := before
+			after.LastActiveAt = &now
+			h.record(r, before.OrgID, audit.ActionUpdate, audit.ResourceMaintenance, before.ID, before, after)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetEndedMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := h.storage.GetPendingMaintenanceSummaries(r.Context(), orgFrom(r))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get ended maintenance windows")
+		return
+	}
+	utils.WriteJSON(h.log, w, h.windowsByState(r, windows, false), http.StatusOK)
+}
+
+func (h *Handler) handleMarkMaintenanceSummarySent(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	before, ok := forChange(h, w, r, id, h.storage.GetMaintenanceWindowByID)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if err := h.storage.MarkMaintenanceSummarySent(r.Context(), orgFrom(r), id, now); err != nil {
+		h.writeError(w, r, err, "Failed to mark maintenance summary sent", "id", id)
+		return
+	}
+	after := *before
+	after.SummarySentAt = &now
+	h.record(r, before.OrgID, audit.ActionUpdate, audit.ResourceMaintenance, id, before, after)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetMaintenanceWindowByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	mw, err := h.storage.GetMaintenanceWindowByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get maintenance window by ID", "id", id)
+		return
+	}
+	if mw == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	utils.WriteJSON(h.log, w, mw, http.StatusOK)
+}
+
+func (h *Handler) handleAddMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var mw storage.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&mw); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddMaintenanceWindow", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	maintenance.Normalize(&mw)
+	if err := maintenance.Validate(mw); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mw.OrgID = ownerOrg(r)
+	id, err := h.storage.AddMaintenanceWindow(r.Context(), mw.OrgID, mw)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add maintenance window", "name", mw.Name)
+		return
+	}
+
+	mw.ID = id
+	h.record(r, mw.OrgID, audit.ActionCreate, audit.ResourceMaintenance, id, nil, mw)
+	h.log.Ctx(r.Context()).Infow("Maintenance window added", "id", id, "name", mw.Name)
+	utils.WriteJSON(h.log, w, mw, http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var mw storage.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&mw); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for UpdateMaintenanceWindow", "id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	mw.ID = id
+
+	maintenance.Normalize(&mw)
+	if err := maintenance.Validate(mw); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, ok := forChange(h, w, r, id, h.storage.GetMaintenanceWindowByID)
+	if !ok {
+		return
+	}
+	mw.OrgID, mw.CreatedAt = before.OrgID, before.CreatedAt
+	mw.LastActiveAt, mw.SummarySentAt = before.LastActiveAt, before.SummarySentAt
+
+	if err := h.storage.UpdateMaintenanceWindow(r.Context(), mw.OrgID, mw); err != nil {
+		h.writeError(w, r, err, "Failed to update maintenance window", "id", id)
+		return
+	}
+	h.record(r, mw.OrgID, audit.ActionUpdate, audit.ResourceMaintenance, id, before, mw)
+
+	h.log.Ctx(r.Context()).Infow("Maintenance window updated", "id", id, "name", mw.Name)
+	utils.WriteJSON(h.log, w, mw, http.StatusOK)
+}
+
+func (h *Handler) handleDeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	before, ok := forChange(h, w, r, id, h.storage.GetMaintenanceWindowByID)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteMaintenanceWindow(r.Context(), before.OrgID, id); err != nil {
+		h.writeError(w, r, err, "Failed to delete maintenance window", "id", id)
+		return
+	}
+	h.record(r, before.OrgID, audit.ActionDelete, audit.ResourceMaintenance, id, before, nil)
+
+	h.log.Ctx(r.Context()).Infow("Maintenance window deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
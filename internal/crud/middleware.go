@@ -0,0 +1,94 @@
+package crud
+
+import (
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+
+	"site-monitor/pkg/logger"
+	"site-monitor/pkg/metrics"
+	"site-monitor/pkg/utils"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	maxRequestIDLen = 128
+
+	unmatchedRoute = "unmatched"
+)
+
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = uuid.New().String()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// AccessLog labels metrics by route pattern, so /sites/{id} is one series.
+func AccessLog(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			duration := time.Since(start)
+
+			metrics.HTTPRequestsTotal.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+			metrics.HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(duration.Seconds())
+
+			log.Ctx(r.Context()).Infow("HTTP request",
+				"method", r.Method,
+				"route", route,
+				"path", r.URL.Path,
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"duration_ms", duration.Milliseconds(),
+			)
+		})
+	}
+}
+
+func Recoverer(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				log.Ctx(r.Context()).Errorw("Panic in HTTP handler",
+					"panic", rec,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"stack", string(debug.Stack()),
+				)
+				utils.WriteProblem(w, r, http.StatusInternalServerError, "")
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
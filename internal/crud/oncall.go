@@ -0,0 +1,383 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"site-monitor/internal/audit"
+	"site-monitor/internal/oncall"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+func (h *Handler) registerOnCallRoutes(r chi.Router) {
+	r.Get("/schedules", h.handleGetSchedules)
+	r.Get("/schedules/{id}", h.handleGetScheduleByID)
+	r.Post("/schedules", h.handleAddSchedule)
+	r.Put("/schedules/{id}", h.handleUpdateSchedule)
+	r.Delete("/schedules/{id}", h.handleDeleteSchedule)
+	r.Get("/schedules/{id}/oncall", h.handleGetOnCall)
+	r.Get("/schedules/{id}/overrides", h.handleGetOverrides)
+	r.Post("/schedules/{id}/overrides", h.handleAddOverride)
+	r.Delete("/schedules/{id}/overrides/{overrideID}", h.handleDeleteOverride)
+
+	r.Get("/escalation-policies", h.handleGetPolicies)
+	r.Get("/escalation-policies/{id}", h.handleGetPolicyByID)
+	r.Post("/escalation-policies", h.handleAddPolicy)
+	r.Put("/escalation-policies/{id}", h.handleUpdatePolicy)
+	r.Delete("/escalation-policies/{id}", h.handleDeletePolicy)
+}
+
+func (h *Handler) handleGetSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.storage.GetOnCallSchedules(r.Context(), orgFrom(r))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get schedules")
+		return
+	}
+	utils.WriteJSON(h.log, w, schedules, http.StatusOK)
+}
+
+func (h *Handler) handleGetScheduleByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	s, err := h.storage.GetOnCallScheduleByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get schedule by ID", "id", id)
+		return
+	}
+	if s == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	utils.WriteJSON(h.log, w, s, http.StatusOK)
+}
+
+func (h *Handler) handleAddSchedule(w http.ResponseWriter, r *http.Request) {
+	var s storage.OnCallSchedule
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddSchedule", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	oncall.Normalize(&s)
+	if err := oncall.ValidateSchedule(s); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.OrgID = ownerOrg(r)
+	id, err := h.storage.AddOnCallSchedule(r.Context(), s.OrgID, s)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add schedule", "name", s.Name)
+		return
+	}
+
+	s.ID = id
+	h.record(r, s.OrgID, audit.ActionCreate, audit.ResourceSchedule, id, nil, s)
+	h.log.Ctx(r.Context()).Infow("Schedule added", "id", id, "team", s.Team)
+	utils.WriteJSON(h.log, w, s, http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var s storage.OnCallSchedule
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for UpdateSchedule", "id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	s.ID = id
+
+	oncall.Normalize(&s)
+	if err := oncall.ValidateSchedule(s); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, ok := forChange(h, w, r, id, h.storage.GetOnCallScheduleByID)
+	if !ok {
+		return
+	}
+	s.OrgID, s.CreatedAt = before.OrgID, before.CreatedAt
+
+	if err := h.storage.UpdateOnCallSchedule(r.Context(), s.OrgID, s); err != nil {
+		h.writeError(w, r, err, "Failed to update schedule", "id", id)
+		return
+	}
+	h.record(r, s.OrgID, audit.ActionUpdate, audit.ResourceSchedule, id, before, s)
+
+	h.log.Ctx(r.Context()).Infow("Schedule updated", "id", id, "team", s.Team)
+	utils.WriteJSON(h.log, w, s, http.StatusOK)
+}
+
+func (h *Handler) handleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	before, ok := forChange(h, w, r, id, h.storage.GetOnCallScheduleByID)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteOnCallSchedule(r.Context(), before.OrgID, id); err != nil {
+		h.writeError(w, r, err, "Failed to delete schedule", "id", id)
+		return
+	}
+	h.record(r, before.OrgID, audit.ActionDelete, audit.ResourceSchedule, id, before, nil)
+
+	h.log.Ctx(r.Context()).Infow("Schedule deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetOnCall(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	at := time.Now()
+	if v := r.URL.Query().Get("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.WriteProblem(w, r, http.StatusBadRequest, "at must be an RFC 3339 timestamp")
+			return
+		}
+		at = t
+	}
+
+	s, err := h.storage.GetOnCallScheduleByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get schedule by ID", "id", id)
+		return
+	}
+	if s == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	overrides, err := h.storage.GetOnCallOverrides(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get overrides", "schedule_id", id)
+		return
+	}
+
+	p, err := oncall.WhoIsOnCall(*s, overrides, at)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to resolve on-call participant", "schedule_id", id)
+		return
+	}
+	if p == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "nobody is on call")
+		return
+	}
+	utils.WriteJSON(h.log, w, p, http.StatusOK)
+}
+
+func (h *Handler) handleGetOverrides(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	overrides, err := h.storage.GetOnCallOverrides(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get overrides", "schedule_id", id)
+		return
+	}
+	utils.WriteJSON(h.log, w, overrides, http.StatusOK)
+}
+
+func (h *Handler) handleAddOverride(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var o storage.OnCallOverride
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddOverride", "schedule_id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	o.ScheduleID = id
+
+	if err := oncall.ValidateOverride(o); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The override lives in the schedule's organization, which also keeps
+	// callers from attaching overrides to another tenant's schedule.
+	s, err := h.storage.GetOnCallScheduleByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get schedule", "id", id)
+		return
+	}
+	if s == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	overrideID, err := h.storage.AddOnCallOverride(r.Context(), s.OrgID, o)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add override", "schedule_id", id)
+		return
+	}
+
+	o.ID = overrideID
+	h.record(r, s.OrgID, audit.ActionCreate, audit.ResourceOverride, overrideID, nil, o)
+	h.log.Ctx(r.Context()).Infow("Override added", "id", overrideID, "schedule_id", id, "name", o.Name)
+	utils.WriteJSON(h.log, w, o, http.StatusCreated)
+}
+
+func (h *Handler) handleDeleteOverride(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	overrideID, ok := uuidParam(w, r, "overrideID")
+	if !ok {
+		return
+	}
+	s, ok := forChange(h, w, r, id, h.storage.GetOnCallScheduleByID)
+	if !ok {
+		return
+	}
+	overrides, err := h.storage.GetOnCallOverrides(r.Context(), s.OrgID, id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get overrides", "schedule_id", id)
+		return
+	}
+	i := slices.IndexFunc(overrides, func(o storage.OnCallOverride) bool { return o.ID == overrideID })
+	if i < 0 {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	if err := h.storage.DeleteOnCallOverride(r.Context(), s.OrgID, id, overrideID); err != nil {
+		h.writeError(w, r, err, "Failed to delete override", "schedule_id", id, "id", overrideID)
+		return
+	}
+	h.record(r, s.OrgID, audit.ActionDelete, audit.ResourceOverride, overrideID, overrides[i], nil)
+
+	h.log.Ctx(r.Context()).Infow("Override deleted", "schedule_id", id, "id", overrideID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.storage.GetEscalationPolicies(r.Context(), orgFrom(r))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get escalation policies")
+		return
+	}
+	utils.WriteJSON(h.log, w, policies, http.StatusOK)
+}
+
+func (h *Handler) handleGetPolicyByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	p, err := h.storage.GetEscalationPolicyByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get escalation policy by ID", "id", id)
+		return
+	}
+	if p == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	utils.WriteJSON(h.log, w, p, http.StatusOK)
+}
+
+func (h *Handler) handleAddPolicy(w http.ResponseWriter, r *http.Request) {
+	var p storage.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddPolicy", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := oncall.ValidatePolicy(p); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p.OrgID = ownerOrg(r)
+	id, err := h.storage.AddEscalationPolicy(r.Context(), p.OrgID, p)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add escalation policy", "name", p.Name)
+		return
+	}
+
+	p.ID = id
+	h.record(r, p.OrgID, audit.ActionCreate, audit.ResourcePolicy, id, nil, p)
+	h.log.Ctx(r.Context()).Infow("Escalation policy added", "id", id, "team", p.Team)
+	utils.WriteJSON(h.log, w, p, http.StatusCreated)
+}
+
+func (h *Handler) handleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var p storage.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for UpdatePolicy", "id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	p.ID = id
+
+	if err := oncall.ValidatePolicy(p); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, ok := forChange(h, w, r, id, h.storage.GetEscalationPolicyByID)
+	if !ok {
+		return
+	}
+	p.OrgID, p.CreatedAt = before.OrgID, before.CreatedAt
+
+	if err := h.storage.UpdateEscalationPolicy(r.Context(), p.OrgID, p); err != nil {
+		h.writeError(w, r, err, "Failed to update escalation policy", "id", id)
+		return
+	}
+	h.record(r, p.OrgID, audit.ActionUpdate, audit.ResourcePolicy, id, before, p)
+
+	h.log.Ctx(r.Context()).Infow("Escalation policy updated", "id", id, "team", p.Team)
+	utils.WriteJSON(h.log, w, p, http.StatusOK)
+}
+
+func (h *Handler) handleDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	before, ok := forChange(h, w, r, id, h.storage.GetEscalationPolicyByID)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteEscalationPolicy(r.Context(), before.OrgID, id); err != nil {
+		h.writeError(w, r, err, "Failed to delete escalation policy", "id", id)
+		return
+	}
+	h.record(r, before.OrgID, audit.ActionDelete, audit.ResourcePolicy, id, before, nil)
+
+	h.log.Ctx(r.Context()).Infow("Escalation policy deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
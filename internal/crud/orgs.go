@@ -0,0 +1,118 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"site-monitor/internal/auth"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+const orgHeader = "X-Org-ID"
+
+type orgKey struct{}
+
+func (h *Handler) registerOrgRoutes(r chi.Router) {
+	r.Get("/orgs", h.handleGetOrgs)
+	r.Post("/orgs", h.handleAddOrg)
+}
+
+// resolveOrg lets platform-wide callers see every tenant unless they pick one
+// with X-Org-ID; everyone else is bound to their own.
+func (h *Handler) resolveOrg(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := auth.PrincipalFrom(r.Context())
+		requested := r.Header.Get(orgHeader)
+
+		orgID := p.OrgID
+		switch {
+		case !p.Global():
+			if requested != "" && requested != p.OrgID {
+				utils.WriteProblem(w, r, http.StatusForbidden, "credentials belong to another organization")
+				return
+			}
+		case requested == "":
+			orgID = storage.AllOrgs
+		default:
+			if _, err := uuid.Parse(requested); err != nil {
+				utils.WriteProblem(w, r, http.StatusBadRequest, "invalid "+orgHeader+" header")
+				return
+			}
+			org, err := h.storage.GetOrganizationByID(r.Context(), requested)
+			if err != nil {
+				h.writeError(w, r, err, "Failed to get organization", "id", requested)
+				return
+			}
+			if org == nil {
+				utils.WriteProblem(w, r, http.StatusNotFound, "organization not found")
+				return
+			}
+			orgID = requested
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), orgKey{}, orgID)))
+	})
+}
+
+func orgFrom(r *http.Request) string {
+	if orgID, ok := r.Context().Value(orgKey{}).(string); ok {
+		return orgID
+	}
+	return storage.AllOrgs
+}
+
+// ownerOrg is the default organization for platform-wide callers that didn't
+// pick one.
+func ownerOrg(r *http.Request) string {
+	if orgID := orgFrom(r); orgID != storage.AllOrgs {
+		return orgID
+	}
+	return storage.DefaultOrgID
+}
+
+type whoami struct {
+	auth.Principal
+	Global bool `json:"global"`
+}
+
+func (h *Handler) handleWhoAmI(w http.ResponseWriter, r *http.Request) {
+	p, _ := auth.PrincipalFrom(r.Context())
+	utils.WriteJSON(h.log, w, whoami{Principal: p, Global: p.Global()}, http.StatusOK)
+}
+
+func (h *Handler) handleGetOrgs(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.storage.GetOrganizations(r.Context())
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get organizations")
+		return
+	}
+	utils.WriteJSON(h.log, w, orgs, http.StatusOK)
+}
+
+func (h *Handler) handleAddOrg(w http.ResponseWriter, r *http.Request) {
+	var org storage.Organization
+	if err := json.NewDecoder(r.Body).Decode(&org); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddOrg", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if org.Name == "" {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	id, err := h.storage.AddOrganization(r.Context(), org)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add organization", "name", org.Name)
+		return
+	}
+
+	org.ID = id
+	h.log.Ctx(r.Context()).Infow("Organization added", "id", id, "name", org.Name)
+	utils.WriteJSON(h.log, w, org, http.StatusCreated)
+}
@@ -0,0 +1,104 @@
+package crud
+
+import (
+	"net/http"
+	"strconv"
+
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+const (
+	stateUp      = "up"
+	stateDown    = "down"
+	statePaused  = "paused"
+	stateUnknown = "unknown"
+)
+
+type siteStatus struct {
+	SiteID    string               `json:"site_id"`
+	URL       string               `json:"url"`
+	State     string               `json:"state"`
+	LastCheck *storage.CheckResult `json:"last_check"`
+	Incident  *storage.Incident    `json:"incident,omitempty"`
+}
+
+func (h *Handler) handleGetSiteStatus(w http.ResponseWriter, r *http.Request) {
+	site, ok := h.siteParam(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := h.storage.GetCheckResults(r.Context(), site.OrgID, site.ID, 1)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get check results", "id", site.ID)
+		return
+	}
+	incidents, err := h.storage.GetIncidents(r.Context(), site.OrgID, storage.IncidentOpen)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get incidents")
+		return
+	}
+
+	status := siteStatus{SiteID: site.ID, URL: site.URL, State: stateUnknown}
+	if len(results) > 0 {
+		status.LastCheck = &results[0]
+		status.State = stateDown
+		if results[0].Up {
+			status.State = stateUp
+		}
+	}
+	if !site.Active {
+		status.State = statePaused
+	}
+	for _, inc := range incidents {
+		if inc.SiteID == site.ID || inc.URL == site.URL {
+			status.Incident = &inc
+			break
+		}
+	}
+	utils.WriteJSON(h.log, w, status, http.StatusOK)
+}
+
+func (h *Handler) handleGetSiteResults(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			utils.WriteProblem(w, r, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	site, ok := h.siteParam(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := h.storage.GetCheckResults(r.Context(), site.OrgID, site.ID, limit)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get check results", "id", site.ID)
+		return
+	}
+	if results == nil {
+		results = []storage.CheckResult{}
+	}
+	utils.WriteJSON(h.log, w, results, http.StatusOK)
+}
+
+func (h *Handler) siteParam(w http.ResponseWriter, r *http.Request) (*storage.Site, bool) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+	site, err := h.storage.GetSiteByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get site by ID", "id", id)
+		return nil, false
+	}
+	if site == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return nil, false
+	}
+	return site, true
+}
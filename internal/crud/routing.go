@@ -0,0 +1,329 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"site-monitor/internal/audit"
+	"site-monitor/internal/routing"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+type routePreview struct {
+	SiteID   string                        `json:"site_id"`
+	Severity string                        `json:"severity"`
+	At       time.Time                     `json:"at"`
+	Routes   []storage.Route               `json:"routes"`
+	Channels []storage.NotificationChannel `json:"channels"`
+	Fallback bool                          `json:"fallback"`
+}
+
+func (h *Handler) registerRoutingRoutes(r chi.Router) {
+	r.Get("/channels", h.handleGetChannels)
+	r.Get("/channels/{id}", h.handleGetChannelByID)
+	r.Post("/channels", h.handleAddChannel)
+	r.Put("/channels/{id}", h.handleUpdateChannel)
+	r.Delete("/channels/{id}", h.handleDeleteChannel)
+
+	r.Get("/routes", h.handleGetRoutes)
+	r.Get("/routes/{id}", h.handleGetRouteByID)
+	r.Post("/routes", h.handleAddRoute)
+	r.Put("/routes/{id}", h.handleUpdateRoute)
+	r.Delete("/routes/{id}", h.handleDeleteRoute)
+
+	r.Get("/sites/{id}/route", h.handlePreviewRoute)
+}
+
+func (h *Handler) handleGetChannels(w http.ResponseWriter, r *http.Request) {
+	channels, err := h.storage.GetChannels(r.Context(), orgFrom(r))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get channels")
+		return
+	}
+	utils.WriteJSON(h.log, w, redactChannels(channels), http.StatusOK)
+}
+
+func (h *Handler) handleGetChannelByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	c, err := h.storage.GetChannelByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get channel by ID", "id", id)
+		return
+	}
+	if c == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	utils.WriteJSON(h.log, w, redactChannel(c), http.StatusOK)
+}
+
+func (h *Handler) handleAddChannel(w http.ResponseWriter, r *http.Request) {
+	var c storage.NotificationChannel
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddChannel", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := routing.ValidateChannel(c); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.OrgID = ownerOrg(r)
+	id, err := h.storage.AddChannel(r.Context(), c.OrgID, c)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add channel", "name", c.Name)
+		return
+	}
+
+	c.ID = id
+	h.record(r, c.OrgID, audit.ActionCreate, audit.ResourceChannel, id, nil, redactChannel(&c))
+	h.log.Ctx(r.Context()).Infow("Channel added", "id", id, "type", c.Type)
+	utils.WriteJSON(h.log, w, redactChannel(&c), http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var c storage.NotificationChannel
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for UpdateChannel", "id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	c.ID = id
+
+	before, ok := forChange(h, w, r, id, h.storage.GetChannelByID)
+	if !ok {
+		return
+	}
+	c.OrgID, c.CreatedAt = before.OrgID, before.CreatedAt
+	keepSecrets(&c, before)
+
+	if err := routing.ValidateChannel(c); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.storage.UpdateChannel(r.Context(), c.OrgID, c); err != nil {
+		h.writeError(w, r, err, "Failed to update channel", "id", id)
+		return
+	}
+	h.record(r, c.OrgID, audit.ActionUpdate, audit.ResourceChannel, id, redactChannel(before), redactChannel(&c))
+
+	h.log.Ctx(r.Context()).Infow("Channel updated", "id", id, "type", c.Type)
+	utils.WriteJSON(h.log, w, redactChannel(&c), http.StatusOK)
+}
+
+func (h *Handler) handleDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	before, ok := forChange(h, w, r, id, h.storage.GetChannelByID)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteChannel(r.Context(), before.OrgID, id); err != nil {
+		h.writeError(w, r, err, "Failed to delete channel", "id", id)
+		return
+	}
+	h.record(r, before.OrgID, audit.ActionDelete, audit.ResourceChannel, id, redactChannel(before), nil)
+
+	h.log.Ctx(r.Context()).Infow("Channel deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetRoutes(w http.ResponseWriter, r *http.Request) {
+	routes, err := h.storage.GetRoutes(r.Context(), orgFrom(r))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get routes")
+		return
+	}
+	utils.WriteJSON(h.log, w, routes, http.StatusOK)
+}
+
+func (h *Handler) handleGetRouteByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	route, err := h.storage.GetRouteByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get route by ID", "id", id)
+		return
+	}
+	if route == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	utils.WriteJSON(h.log, w, route, http.StatusOK)
+}
+
+func (h *Handler) handleAddRoute(w http.ResponseWriter, r *http.Request) {
+	var route storage.Route
+	if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddRoute", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	routing.NormalizeRoute(&route)
+	if err := routing.ValidateRoute(route); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	route.OrgID = ownerOrg(r)
+	id, err := h.storage.AddRoute(r.Context(), route.OrgID, route)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add route", "name", route.Name)
+		return
+	}
+
+	route.ID = id
+	h.record(r, route.OrgID, audit.ActionCreate, audit.ResourceRoute, id, nil, route)
+	h.log.Ctx(r.Context()).Infow("Route added", "id", id, "name", route.Name)
+	utils.WriteJSON(h.log, w, route, http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateRoute(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var route storage.Route
+	if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for UpdateRoute", "id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	route.ID = id
+
+	routing.NormalizeRoute(&route)
+	if err := routing.ValidateRoute(route); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, ok := forChange(h, w, r, id, h.storage.GetRouteByID)
+	if !ok {
+		return
+	}
+	route.OrgID, route.CreatedAt = before.OrgID, before.CreatedAt
+
+	if err := h.storage.UpdateRoute(r.Context(), route.OrgID, route); err != nil {
+		h.writeError(w, r, err, "Failed to update route", "id", id)
+		return
+	}
+	h.record(r, route.OrgID, audit.ActionUpdate, audit.ResourceRoute, id, before, route)
+
+	h.log.Ctx(r.Context()).Infow("Route updated", "id", id, "name", route.Name)
+	utils.WriteJSON(h.log, w, route, http.StatusOK)
+}
+
+func (h *Handler) handleDeleteRoute(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	before, ok := forChange(h, w, r, id, h.storage.GetRouteByID)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteRoute(r.Context(), before.OrgID, id); err != nil {
+		h.writeError(w, r, err, "Failed to delete route", "id", id)
+		return
+	}
+	h.record(r, before.OrgID, audit.ActionDelete, audit.ResourceRoute, id, before, nil)
+
+	h.log.Ctx(r.Context()).Infow("Route deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handlePreviewRoute(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	severity := r.URL.Query().Get("severity")
+	if severity == "" {
+		severity = routing.SeverityCritical
+	}
+	at := time.Now()
+	if v := r.URL.Query().Get("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.WriteProblem(w, r, http.StatusBadRequest, "at must be an RFC 3339 timestamp")
+			return
+		}
+		at = t
+	}
+
+	site, err := h.storage.GetSiteByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get site by ID", "id", id)
+		return
+	}
+	if site == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	routes, err := h.storage.GetRoutes(r.Context(), site.OrgID)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get routes")
+		return
+	}
+	channels, err := h.storage.GetChannels(r.Context(), site.OrgID)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get channels")
+		return
+	}
+
+	matched := routing.Resolve(routes, routing.Alert{Tags: site.Tags, Team: site.Team, Severity: severity, Time: at})
+
+	preview := routePreview{
+		SiteID:   id,
+		Severity: severity,
+		At:       at,
+		Routes:   []storage.Route{},
+		Channels: []storage.NotificationChannel{},
+		Fallback: len(matched) == 0 && routing.FallbackAllowed(site.OrgID),
+	}
+	seen := make(map[string]bool)
+	for _, route := range matched {
+		preview.Routes = append(preview.Routes, route)
+		for _, c := range channels {
+			if !seen[c.ID] && contains(route.ChannelIDs, c.ID) {
+				seen[c.ID] = true
+				preview.Channels = append(preview.Channels, *redactChannel(&c))
+			}
+		}
+	}
+	utils.WriteJSON(h.log, w, preview, http.StatusOK)
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"site-monitor/internal/audit"
+	"site-monitor/internal/silence"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/utils"
+)
+
+type ackRequest struct {
+	By      string `json:"by"`
+	Comment string `json:"comment"`
+}
+
+func (h *Handler) registerSilenceRoutes(r chi.Router) {
+	r.Get("/silences", h.handleGetSilences)
+	r.Get("/silences/{id}", h.handleGetSilenceByID)
+	r.Get("/silences/{id}/events", h.handleGetSilenceEvents)
+	r.Post("/silences", h.handleAddSilence)
+	r.Put("/silences/{id}", h.handleUpdateSilence)
+	r.Delete("/silences/{id}", h.handleDeleteSilence)
+
+	r.Get("/suppressed-events", h.handleGetSuppressedEvents)
+
+	r.Get("/incidents", h.handleGetIncidents)
+	r.Get("/incidents/{id}", h.handleGetIncidentByID)
+	r.Post("/incidents/{id}/ack", h.handleAcknowledgeIncident)
+}
+
+func (h *Handler) handleGetSilences(w http.ResponseWriter, r *http.Request) {
+	activeOnly := r.URL.Query().Get("active") == "true"
+	silences, err := h.storage.GetSilences(r.Context(), orgFrom(r), activeOnly)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get silences")
+		return
+	}
+	utils.WriteJSON(h.log, w, silences, http.StatusOK)
+}
+
+func (h *Handler) handleGetSilenceByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	s, err := h.storage.GetSilenceByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get silence by ID", "id", id)
+		return
+	}
+	if s == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	utils.WriteJSON(h.log, w, s, http.StatusOK)
+}
+
+func (h *Handler) handleGetSilenceEvents(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	events, err := h.storage.GetSuppressedEvents(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get suppressed events", "silence_id", id)
+		return
+	}
+	utils.WriteJSON(h.log, w, events, http.StatusOK)
+}
+
+func (h *Handler) handleAddSilence(w http.ResponseWriter, r *http.Request) {
+	var s storage.Silence
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AddSilence", "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := silence.Validate(s, time.Now()); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.OrgID = ownerOrg(r)
+	id, err := h.storage.AddSilence(r.Context(), s.OrgID, s)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add silence")
+		return
+	}
+
+	s.ID = id
+	h.record(r, s.OrgID, audit.ActionCreate, audit.ResourceSilence, id, nil, s)
+	h.log.Ctx(r.Context()).Infow("Silence added", "id", id, "expires_at", s.ExpiresAt, "created_by", s.CreatedBy)
+	utils.WriteJSON(h.log, w, s, http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateSilence(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var s storage.Silence
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for UpdateSilence", "id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	s.ID = id
+
+	if err := silence.Validate(s, time.Now()); err != nil {
+		utils.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, ok := forChange(h, w, r, id, h.storage.GetSilenceByID)
+	if !ok {
+		return
+	}
+	s.OrgID, s.CreatedBy, s.CreatedAt = before.OrgID, before.CreatedBy, before.CreatedAt
+
+	if err := h.storage.UpdateSilence(r.Context(), s.OrgID, s); err != nil {
+		h.writeError(w, r, err, "Failed to update silence", "id", id)
+		return
+	}
+	h.record(r, s.OrgID, audit.ActionUpdate, audit.ResourceSilence, id, before, s)
+
+	h.log.Ctx(r.Context()).Infow("Silence updated", "id", id, "expires_at", s.ExpiresAt)
+	utils.WriteJSON(h.log, w, s, http.StatusOK)
+}
+
+func (h *Handler) handleDeleteSilence(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	before, ok := forChange(h, w, r, id, h.storage.GetSilenceByID)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteSilence(r.Context(), before.OrgID, id); err != nil {
+		h.writeError(w, r, err, "Failed to delete silence", "id", id)
+		return
+	}
+	h.record(r, before.OrgID, audit.ActionDelete, audit.ResourceSilence, id, before, nil)
+
+	h.log.Ctx(r.Context()).Infow("Silence deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetSuppressedEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := h.storage.GetSuppressedEvents(r.Context(), orgFrom(r), r.URL.Query().Get("silence_id"))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get suppressed events")
+		return
+	}
+	utils.WriteJSON(h.log, w, events, http.StatusOK)
+}
+
+func (h *Handler) handleGetIncidents(w http.ResponseWriter, r *http.Request) {
+	incidents, err := h.storage.GetIncidents(r.Context(), orgFrom(r), r.URL.Query().Get("status"))
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get incidents")
+		return
+	}
+	utils.WriteJSON(h.log, w, incidents, http.StatusOK)
+}
+
+func (h *Handler) handleGetIncidentByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+	inc, err := h.storage.GetIncidentByID(r.Context(), orgFrom(r), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get incident by ID", "id", id)
+		return
+	}
+	if inc == nil {
+		utils.WriteProblem(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	utils.WriteJSON(h.log, w, inc, http.StatusOK)
+}
+
+func (h *Handler) handleAcknowledgeIncident(w http.ResponseWriter, r *http.Request) {
+	id, ok := uuidParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req ackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Ctx(r.Context()).Warnw("Invalid request body for AcknowledgeIncident", "id", id, "error", err)
+		utils.WriteProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.By == "" {
+		utils.WriteProblem(w, r, http.StatusBadRequest, "by is required")
+		return
+	}
+
+	before, ok := forChange(h, w, r, id, h.storage.GetIncidentByID)
+	if !ok {
+		return
+	}
+	if before.Status != storage.IncidentOpen {
+		utils.WriteProblem(w, r, http.StatusConflict, "incident is not open")
+		return
+	}
+
+	if err := h.storage.AcknowledgeIncident(r.Context(), before.OrgID, id, req.By, req.Comment); err != nil {
+		h.writeError(w, r, err, "Failed to acknowledge incident", "id", id)
+		return
+	}
+
+	inc, err := h.storage.GetIncidentByID(r.Context(), before.OrgID, id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get incident by ID", "id", id)
+		return
+	}
+	h.record(r, before.OrgID, audit.ActionUpdate, audit.ResourceIncident, id, before, inc)
+
+	h.log.Ctx(r.Context()).Infow("Incident acknowledged", "id", id, "by", req.By)
+	utils.WriteJSON(h.log, w, inc, http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"site-monitor/internal/storage"
+)
+
+const (
+	ScheduleOnce   = "once"
+	ScheduleCron   = "cron"
+	ScheduleWeekly = "weekly"
+
+	SuppressAlerts = "alerts"
+	SuppressChecks = "checks"
+)
+
+const maxWeeklyDuration = 7 * 24 * time.Hour
+
+func Normalize(w *storage.MaintenanceWindow) {
+	if w.ScheduleType == "" {
+		w.ScheduleType = ScheduleOnce
+	}
+	if w.Suppress == "" {
+		w.Suppress = SuppressAlerts
+	}
+	if w.Timezone == "" {
+		w.Timezone = "UTC"
+	}
+}
+
+func Validate(w storage.MaintenanceWindow) error {
+	var errs []error
+
+	if w.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if len(w.SiteIDs) == 0 && len(w.Tags) == 0 {
+		errs = append(errs, errors.New("at least one of site_ids or tags is required"))
+	}
+	if w.Suppress != SuppressAlerts && w.Suppress != SuppressChecks {
+		errs = append(errs, fmt.Errorf("suppress must be %q or %q", SuppressAlerts, SuppressChecks))
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("invalid timezone %q", w.Timezone))
+	}
+	if w.StartsAt != nil && w.EndsAt != nil && !w.EndsAt.After(*w.StartsAt) {
+		errs = append(errs, errors.New("ends_at must be after starts_at"))
+	}
+
+	switch w.ScheduleType {
+	case ScheduleOnce:
+		if w.StartsAt == nil || w.EndsAt == nil {
+			errs = append(errs, errors.New("starts_at and ends_at are required for a one-off window"))
+		}
+	case ScheduleCron:
+		if _, err := cron.ParseStandard(w.Cron); err != nil {
+			errs = append(errs, fmt.Errorf("invalid cron expression: %w", err))
+		}
+		if w.DurationMinutes <= 0 {
+			errs = append(errs, errors.New("duration_minutes must be positive"))
+		}
+	case ScheduleWeekly:
+		if len(w.Weekdays) == 0 {
+			errs = append(errs, errors.New("weekdays are required for a weekly window"))
+		}
+		for _, d := range w.Weekdays {
+			if d < 0 || d > 6 {
+				errs = append(errs, fmt.Errorf("invalid weekday %d, expected 0 (Sunday) to 6", d))
+			}
+		}
+		if _, err := time.Parse("15:04", w.StartTime); err != nil {
+			errs = append(errs, fmt.Errorf("invalid start_time %q, expected HH:MM", w.StartTime))
+		}
+		if w.DurationMinutes <= 0 || time.Duration(w.DurationMinutes)*time.Minute > maxWeeklyDuration {
+			errs = append(errs, errors.New("duration_minutes must be between 1 and 10080"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown schedule_type %q", w.ScheduleType))
+	}
+
+	return errors.Join(errs...)
+}
+
+func IsActive(w storage.MaintenanceWindow, t time.Time) (bool, error) {
+	if w.StartsAt != nil && t.Before(*w.StartsAt) {
+		return false, nil
+	}
+	if w.EndsAt != nil && !t.Before(*w.EndsAt) {
+		return false, nil
+	}
+
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return false, err
+	}
+	t = t.In(loc)
+	duration := time.Duration(w.DurationMinutes) * time.Minute
+
+	switch w.ScheduleType {
+	case ScheduleOnce:
+		return w.StartsAt != nil && w.EndsAt != nil, nil
+	case ScheduleCron:
+		sched, err := cron.ParseStandard(w.Cron)
+		if err != nil {
+			return false, err
+		}
+		start := sched.Next(t.Add(-duration))
+		return !start.After(t), nil
+	case ScheduleWeekly:
+		startTime, err := time.Parse("15:04", w.StartTime)
+		if err != nil {
+			return false, err
+		}
+		for offset := 0; offset <= 7; offset++ {
+			day := t.AddDate(0, 0, -offset)
+			if !containsWeekday(w.Weekdays, day.Weekday()) {
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), startTime.Hour(), startTime.Minute(), 0, 0, loc)
+			if !start.After(t) && t.Before(start.Add(duration)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return false, fmt.Errorf("unknown schedule_type %q", w.ScheduleType)
+}
+
+func containsWeekday(days []int, d time.Weekday) bool {
+	for _, day := range days {
+		if time.Weekday(day) == d {
+			return true
+		}
+	}
+	return false
+}
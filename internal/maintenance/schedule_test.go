@@ -0,0 +1,146 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"site-monitor/internal/storage"
+)
+
+func TestIsActiveWeekly(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	at := func(loc *time.Location, y int, m time.Month, d, hh, mm int) time.Time {
+		return time.Date(y, m, d, hh, mm, 0, 0, loc)
+	}
+	weekly := func(tz string, days []int, start string, minutes int) storage.MaintenanceWindow {
+		return storage.MaintenanceWindow{ScheduleType: ScheduleWeekly, Timezone: tz, Weekdays: days,
+			StartTime: start, DurationMinutes: minutes}
+	}
+
+	tests := []struct {
+		name string
+		w    storage.MaintenanceWindow
+		t    time.Time
+		want bool
+	}{
+		// 2026-10-17 is a Saturday.
+		{"inside", weekly("UTC", []int{6}, "10:00", 60), at(time.UTC, 2026, 10, 17, 10, 30), true},
+		{"at start", weekly("UTC", []int{6}, "10:00", 60), at(time.UTC, 2026, 10, 17, 10, 0), true},
+		{"at end", weekly("UTC", []int{6}, "10:00", 60), at(time.UTC, 2026, 10, 17, 11, 0), false},
+		{"other weekday", weekly("UTC", []int{5}, "10:00", 60), at(time.UTC, 2026, 10, 17, 10, 30), false},
+		{"across midnight, before", weekly("UTC", []int{6}, "23:00", 120), at(time.UTC, 2026, 10, 17, 23, 30), true},
+		{"across midnight, after", weekly("UTC", []int{6}, "23:00", 120), at(time.UTC, 2026, 10, 18, 0, 30), true},
+		{"across midnight, ended", weekly("UTC", []int{6}, "23:00", 120), at(time.UTC, 2026, 10, 18, 1, 0), false},
+		{"across midnight, Sunday not listed", weekly("UTC", []int{0}, "23:00", 120), at(time.UTC, 2026, 10, 18, 0, 30), false},
+		{"across the week boundary", weekly("UTC", []int{6}, "23:00", 25*60), at(time.UTC, 2026, 10, 19, 0, 0), false},
+		{"in the window's time zone", weekly("Europe/Berlin", []int{6}, "10:00", 60), at(time.UTC, 2026, 10, 17, 8, 30), true},
+		{"not in UTC", weekly("Europe/Berlin", []int{6}, "10:00", 60), at(time.UTC, 2026, 10, 17, 10, 30), false},
+		// On 2026-10-25 Berlin goes from 03:00 CEST back to 02:00 CET, so a
+		// three hour window from 01:00 ends at 03:00 CET.
+		{"DST end, repeated hour", weekly("Europe/Berlin", []int{0}, "01:00", 180), at(time.UTC, 2026, 10, 25, 1, 30), true},
+		{"DST end, after", weekly("Europe/Berlin", []int{0}, "01:00", 180), at(berlin, 2026, 10, 25, 3, 10), false},
+		// On 2026-03-29 Berlin skips from 02:00 CET to 03:00 CEST.
+		{"DST start, skipped hour", weekly("Europe/Berlin", []int{0}, "01:30", 60), at(berlin, 2026, 3, 29, 3, 15), true},
+		{"DST start, after", weekly("Europe/Berlin", []int{0}, "01:30", 60), at(berlin, 2026, 3, 29, 3, 30), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsActive(tt.w, tt.t)
+			if err != nil {
+				t.Fatalf("IsActive: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsActive(%s %v for %dm, %s) = %t, want %t",
+					tt.w.StartTime, tt.w.Weekdays, tt.w.DurationMinutes, tt.t.Format(time.RFC3339), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsActiveCron(t *testing.T) {
+	cron := func(expr, tz string, minutes int) storage.MaintenanceWindow {
+		return storage.MaintenanceWindow{ScheduleType: ScheduleCron, Cron: expr, Timezone: tz, DurationMinutes: minutes}
+	}
+	at := func(d, hh, mm int) time.Time { return time.Date(2026, 10, d, hh, mm, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		w    storage.MaintenanceWindow
+		t    time.Time
+		want bool
+	}{
+		{"at fire time", cron("0 3 * * *", "UTC", 30), at(17, 3, 0), true},
+		{"inside", cron("0 3 * * *", "UTC", 30), at(17, 3, 29), true},
+		{"at end", cron("0 3 * * *", "UTC", 30), at(17, 3, 30), false},
+		{"before first fire of the day", cron("0 3 * * *", "UTC", 30), at(17, 2, 59), false},
+		{"previous day's fire across midnight", cron("0 23 * * *", "UTC", 120), at(18, 0, 30), true},
+		{"every 15 minutes, on fire", cron("*/15 * * * *", "UTC", 5), at(17, 10, 45), true},
+		{"every 15 minutes, inside", cron("*/15 * * * *", "UTC", 5), at(17, 10, 49), true},
+		{"every 15 minutes, at end", cron("*/15 * * * *", "UTC", 5), at(17, 10, 50), false},
+		{"every 15 minutes, between fires", cron("*/15 * * * *", "UTC", 5), at(17, 10, 59), false},
+		{"weekdays only, Saturday", cron("0 3 * * 1-5", "UTC", 30), at(17, 3, 10), false},
+		{"weekdays only, Monday", cron("0 3 * * 1-5", "UTC", 30), at(19, 3, 10), true},
+		{"in the window's time zone", cron("0 3 * * *", "Europe/Berlin", 30), at(17, 1, 10), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsActive(tt.w, tt.t)
+			if err != nil {
+				t.Fatalf("IsActive: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsActive(%q for %dm, %s) = %t, want %t", tt.w.Cron, tt.w.DurationMinutes,
+					tt.t.Format(time.RFC3339), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsActiveBounds(t *testing.T) {
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	once := storage.MaintenanceWindow{ScheduleType: ScheduleOnce, Timezone: "UTC", StartsAt: &start, EndsAt: &end}
+	daily := storage.MaintenanceWindow{ScheduleType: ScheduleCron, Cron: "0 * * * *", Timezone: "UTC",
+		DurationMinutes: 59, StartsAt: &start}
+
+	tests := []struct {
+		name string
+		w    storage.MaintenanceWindow
+		t    time.Time
+		want bool
+	}{
+		{"once, before", once, start.Add(-time.Second), false},
+		{"once, at start", once, start, true},
+		{"once, at end", once, end, false},
+		{"recurring, before starts_at", daily, start.Add(-30 * time.Minute), false},
+		{"recurring, after starts_at", daily, start.Add(90 * time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsActive(tt.w, tt.t)
+			if err != nil {
+				t.Fatalf("IsActive: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsActive(%s) = %t, want %t", tt.t.Format(time.RFC3339), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsActiveInvalid(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	for _, w := range []storage.MaintenanceWindow{
+		{ScheduleType: ScheduleCron, Cron: "not cron", Timezone: "UTC", DurationMinutes: 5},
+		{ScheduleType: ScheduleWeekly, Weekdays: []int{6}, StartTime: "25:00", Timezone: "UTC", DurationMinutes: 5},
+		{ScheduleType: ScheduleWeekly, Weekdays: []int{6}, StartTime: "10:00", Timezone: "Mars/Base", DurationMinutes: 5},
+		{ScheduleType: "monthly", Timezone: "UTC"},
+	} {
+		if _, err := IsActive(w, now); err == nil {
+			t.Errorf("IsActive(%+v): expected an error", w)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package monitors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"site-monitor/internal/sites"
+	"site-monitor/internal/storage"
+	"site-monitor/pkg/client"
+)
+
+// Monitor is one site as defined in a YAML file. ID is its stable external
+// ID: the URL and everything else can change without recreating the site.
+type Monitor struct {
+	ID          string            `yaml:"id"`
+	URL         string            `yaml:"url"`
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Owner       string            `yaml:"owner"`
+	RunbookURL  string            `yaml:"runbook_url"`
+	Active      *bool             `yaml:"active"`
+	Team        string            `yaml:"team"`
+	Tags        []string          `yaml:"tags"`
+	Labels      map[string]string `yaml:"labels"`
+
+	// File is where the monitor was defined, for error messages.
+	File string `yaml:"-"`
+}
+
+func (m Monitor) Site() client.Site {
+	active := true
+	if m.Active != nil {
+		active = *m.Active
+	}
+	s := storage.Site{
+		ExternalID: m.ID, URL: m.URL, Active: active, Tags: m.Tags, Team: m.Team,
+		Name: m.Name, Description: m.Description, Owner: m.Owner, RunbookURL: m.RunbookURL, Labels: m.Labels,
+	}
+	sites.Normalize(&s)
+	return client.Site(s)
+}
+
+// Load reads every *.yaml and *.yml file under dir. A file holds one
+// monitor, a list of them, or several YAML documents of either kind.
+func Load(dir string) ([]Monitor, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(path); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var all []Monitor
+	for _, f := range files {
+		ms, err := loadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		all = append(all, ms...)
+	}
+	return all, validate(all)
+}
+
+func loadFile(path string) ([]Monitor, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []Monitor
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+
+		var ms []Monitor
+		if node.Content[0].Kind == yaml.SequenceNode {
+			err = decodeStrict(&node, &ms)
+		} else {
+			var m Monitor
+			err = decodeStrict(&node, &m)
+			ms = []Monitor{m}
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range ms {
+			ms[i].File = path
+		}
+		all = append(all, ms...)
+	}
+	return all, nil
+}
+
+// decodeStrict decodes a document rejecting unknown keys, so a misspelled
+// field fails instead of being silently dropped. yaml.Node.Decode has no
+// such option, hence the round trip.
+func decodeStrict(node *yaml.Node, out any) error {
+	raw, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	return dec.Decode(out)
+}
+
+func validate(ms []Monitor) error {
+	var errs []error
+	ids := make(map[string]string)
+	urls := make(map[string]string)
+	for _, m := range ms {
+		where := m.File
+		if m.ID == "" {
+			errs = append(errs, fmt.Errorf("%s: monitor for %q has no id", where, m.URL))
+			continue
+		}
+		where += ": " + m.ID
+		if prev, ok := ids[m.ID]; ok {
+			errs = append(errs, fmt.Errorf("%s: id is already used in %s", where, prev))
+		}
+		ids[m.ID] = m.File
+
+		s := m.Site()
+		if err := sites.Validate(storage.Site(s)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", where, err))
+			continue
+		}
+		if prev, ok := urls[s.URL]; ok {
+			errs = append(errs, fmt.Errorf("%s: url %s is already monitored by %s", where, s.URL, prev))
+		}
+		urls[s.URL] = m.ID
+	}
+	return errors.Join(errs...)
+}
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	// ActionIgnore marks a site that is not defined in the files and stays
+	// because the plan doesn't prune.
+	ActionIgnore = "ignore"
+)
+
+// Change is one step of a plan. Site is the desired state, Current the site
+// as the API has it; Fields lists what an update changes.
+type Change struct {
+	Action  string
+	Site    client.Site
+	Current *client.Site
+	Fields  []FieldChange
+}
+
+type FieldChange struct {
+	Field    string
+	From, To any
+}
+
+// Plan is everything that Apply would do.
+type Plan struct {
+	Changes []Change
+}
+
+func (p Plan) Count(action string) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Empty reports whether applying the plan would change anything.
+func (p Plan) Empty() bool {
+	return p.Count(ActionCreate)+p.Count(ActionUpdate)+p.Count(ActionDelete) == 0
+}
+
+// Diff matches monitors to current sites by external ID. A site without an
+// external ID whose URL is defined in the files is adopted instead of being
+// created again. Sites that are not defined are deleted when prune is set
+// and ignored otherwise.
+func Diff(monitors []Monitor, current []client.Site, prune bool) Plan {
+	byExternal := make(map[string]*client.Site)
+	byURL := make(map[string]*client.Site)
+	for i := range current {
+		s := &current[i]
+		if s.ExternalID != "" {
+			byExternal[s.ExternalID] = s
+		} else {
+			byURL[s.URL] = s
+		}
+	}
+
+	var plan Plan
+	matched := make(map[string]bool)
+	for _, m := range monitors {
+		want := m.Site()
+		cur := byExternal[want.ExternalID]
+		if cur == nil {
+			cur = byURL[want.URL]
+		}
+		if cur == nil {
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Site: want})
+			continue
+		}
+
+		matched[cur.ID] = true
+		want.ID, want.OrgID = cur.ID, cur.OrgID
+		if fields := diffSite(*cur, want); len(fields) > 0 {
+			plan.Changes = append(plan.Changes, Change{Action: ActionUpdate, Site: want, Current: cur, Fields: fields})
+		}
+	}
+
+	for i := range current {
+		s := &current[i]
+		if matched[s.ID] {
+			continue
+		}
+		action := ActionIgnore
+		if prune {
+			action = ActionDelete
+		}
+		plan.Changes = append(plan.Changes, Change{Action: action, Site: *s, Current: s})
+	}
+	return plan
+}
+
+// diffSite compares the fields a monitor defines. Empty and missing tags or
+// labels are the same thing.
+func diffSite(cur, want client.Site) []FieldChange {
+	var fields []FieldChange
+	add := func(name string, from, to any) {
+		if !reflect.DeepEqual(from, to) {
+			fields = append(fields, FieldChange{Field: name, From: from, To: to})
+		}
+	}
+	add("external_id", cur.ExternalID, want.ExternalID)
+	add("url", cur.URL, want.URL)
+	add("name", cur.Name, want.Name)
+	add("description", cur.Description, want.Description)
+	add("owner", cur.Owner, want.Owner)
+	add("runbook_url", cur.RunbookURL, want.RunbookURL)
+	add("active", cur.Active, want.Active)
+	add("team", cur.Team, want.Team)
+	add("tags", nonNil(cur.Tags), nonNil(want.Tags))
+	if len(cur.Labels) > 0 || len(want.Labels) > 0 {
+		add("labels", cur.Labels, want.Labels)
+	}
+	return fields
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// Write prints the plan in a diff-like form.
+func (p Plan) Write(w io.Writer) {
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(w, "+ create %s (%s)\n", c.Site.ExternalID, c.Site.URL)
+		case ActionUpdate:
+			fmt.Fprintf(w, "~ update %s (%s)\n", c.Site.ExternalID, c.Current.URL)
+			for _, f := range c.Fields {
+				fmt.Fprintf(w, "    %s: %s -> %s\n", f.Field, show(f.From), show(f.To))
+			}
+		case ActionDelete:
+			fmt.Fprintf(w, "- delete %s\n", describe(c.Site))
+		case ActionIgnore:
+			fmt.Fprintf(w, "  unmanaged %s (kept, use -prune to delete)\n", describe(c.Site))
+		}
+	}
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n",
+		p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete))
+}
+
+func describe(s client.Site) string {
+	if s.ExternalID != "" {
+		return s.ExternalID + " (" + s.URL + ")"
+	}
+	return s.URL
+}
+
+func show(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []string:
+		return "[" + strings.Join(v, ", ") + "]"
+	case map[string]string:
+		pairs := make([]string, 0, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			pairs = append(pairs, k+"="+v[k])
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Apply carries out the plan through the API. Deletes go first and creates
+// last, so a URL can move from a removed site to a new one in a single run.
+// Updates are guarded by the version the plan saw and fail if the site
+// changed since. Apply stops at the first error; what was done is logged to
+// out.
+func Apply(ctx context.Context, c *client.Client, p Plan, out io.Writer) error {
+	for _, action := range []string{ActionDelete, ActionUpdate, ActionCreate} {
+		for _, ch := range p.Changes {
+			if ch.Action != action {
+				continue
+			}
+			var err error
+			switch action {
+			case ActionDelete:
+				err = c.DeleteSite(ctx, ch.Site.ID)
+			case ActionUpdate:
+				_, err = c.UpdateSite(ctx, ch.Site, ch.Current.Version)
+			case ActionCreate:
+				_, err = c.CreateSite(ctx, ch.Site)
+			}
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", action, describe(ch.Site), err)
+			}
+			fmt.Fprintf(out, "%sd %s\n", strings.TrimSuffix(action, "e"), describe(ch.Site))
+		}
+	}
+	return nil
+}
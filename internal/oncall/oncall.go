@@ -0,0 +1,114 @@
+package oncall
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"site-monitor/internal/storage"
+)
+
+func Normalize(s *storage.OnCallSchedule) {
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+}
+
+func ValidateSchedule(s storage.OnCallSchedule) error {
+	var errs []error
+
+	if s.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if s.Team == "" {
+		errs = append(errs, errors.New("team is required"))
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("invalid timezone %q", s.Timezone))
+	}
+	if s.RotationStart.IsZero() {
+		errs = append(errs, errors.New("rotation_start is required"))
+	}
+	if len(s.Participants) == 0 {
+		errs = append(errs, errors.New("at least one participant is required"))
+	}
+	for i, p := range s.Participants {
+		if p.Name == "" || p.ChatID == "" {
+			errs = append(errs, fmt.Errorf("participant %d: name and chat_id are required", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func ValidateOverride(o storage.OnCallOverride) error {
+	var errs []error
+
+	if o.Name == "" || o.ChatID == "" {
+		errs = append(errs, errors.New("name and chat_id are required"))
+	}
+	if !o.EndsAt.After(o.StartsAt) {
+		errs = append(errs, errors.New("ends_at must be after starts_at"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func ValidatePolicy(p storage.EscalationPolicy) error {
+	var errs []error
+
+	if p.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if p.Team == "" {
+		errs = append(errs, errors.New("team is required"))
+	}
+	if len(p.Levels) == 0 {
+		errs = append(errs, errors.New("at least one level is required"))
+	}
+	for i, l := range p.Levels {
+		if l.ScheduleID == "" && len(l.ChatIDs) == 0 {
+			errs = append(errs, fmt.Errorf("level %d: schedule_id or chat_ids is required", i))
+		}
+		if i < len(p.Levels)-1 && l.EscalateAfterMinutes <= 0 {
+			errs = append(errs, fmt.Errorf("level %d: escalate_after_minutes must be positive", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func WhoIsOnCall(s storage.OnCallSchedule, overrides []storage.OnCallOverride, t time.Time) (*storage.OnCallParticipant, error) {
+	for _, o := range overrides {
+		if !t.Before(o.StartsAt) && t.Before(o.EndsAt) {
+			return &storage.OnCallParticipant{Name: o.Name, ChatID: o.ChatID}, nil
+		}
+	}
+
+	if len(s.Participants) == 0 {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	start := s.RotationStart.In(loc)
+	t = t.In(loc)
+	if t.Before(start) {
+		return nil, nil
+	}
+
+	days := int(dateOf(t).Sub(dateOf(start)).Hours() / 24)
+	week := days / 7
+	if t.Before(start.AddDate(0, 0, 7*week)) {
+		week--
+	}
+
+	p := s.Participants[week%len(s.Participants)]
+	return &p, nil
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,200 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"site-monitor/internal/storage"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+
+	ChannelTelegram = "telegram"
+	ChannelSlack    = "slack"
+	ChannelWebhook  = "webhook"
+)
+
+type Alert struct {
+	Tags     []string
+	Team     string
+	Severity string
+	Time     time.Time
+}
+
+func Resolve(routes []storage.Route, a Alert) []storage.Route {
+	var matched []storage.Route
+	for _, r := range routes {
+		if !Matches(r, a) {
+			continue
+		}
+		matched = append(matched, r)
+		if !r.Continue {
+			break
+		}
+	}
+	return matched
+}
+
+// FallbackAllowed reports whether alerts of orgID that match no route may go
+// to the service's default notifiers. Those belong to the operator, so only
+// the default organization falls back to them and other tenants have to
+// route their alerts explicitly.
+func FallbackAllowed(orgID string) bool {
+	return orgID == storage.DefaultOrgID
+}
+
+func Matches(r storage.Route, a Alert) bool {
+	if len(r.Tags) > 0 && !intersects(r.Tags, a.Tags) {
+		return false
+	}
+	if len(r.Teams) > 0 && !contains(r.Teams, a.Team) {
+		return false
+	}
+	if len(r.Severities) > 0 && !contains(r.Severities, a.Severity) {
+		return false
+	}
+	return matchesTime(r, a.Time)
+}
+
+func matchesTime(r storage.Route, t time.Time) bool {
+	if len(r.Weekdays) == 0 && r.StartTime == "" && r.EndTime == "" {
+		return true
+	}
+
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return false
+	}
+	t = t.In(loc)
+
+	if len(r.Weekdays) > 0 {
+		found := false
+		for _, d := range r.Weekdays {
+			if time.Weekday(d) == t.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.StartTime == "" || r.EndTime == "" {
+		return true
+	}
+	start, err1 := time.Parse("15:04", r.StartTime)
+	end, err2 := time.Parse("15:04", r.EndTime)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+func NormalizeRoute(r *storage.Route) {
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+}
+
+func ValidateRoute(r storage.Route) error {
+	var errs []error
+
+	if r.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if len(r.ChannelIDs) == 0 {
+		errs = append(errs, errors.New("at least one channel_id is required"))
+	}
+	for _, s := range r.Severities {
+		if s != SeverityCritical && s != SeverityWarning && s != SeverityInfo {
+			errs = append(errs, fmt.Errorf("unknown severity %q", s))
+		}
+	}
+	for _, d := range r.Weekdays {
+		if d < 0 || d > 6 {
+			errs = append(errs, fmt.Errorf("invalid weekday %d, expected 0 (Sunday) to 6", d))
+		}
+	}
+	if (r.StartTime == "") != (r.EndTime == "") {
+		errs = append(errs, errors.New("start_time and end_time must be set together"))
+	}
+	for _, v := range []string{r.StartTime, r.EndTime} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", v); err != nil {
+			errs = append(errs, fmt.Errorf("invalid time %q, expected HH:MM", v))
+		}
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("invalid timezone %q", r.Timezone))
+	}
+
+	return errors.Join(errs...)
+}
+
+func ValidateChannel(c storage.NotificationChannel) error {
+	var errs []error
+
+	if c.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+
+	switch c.Type {
+	case ChannelTelegram:
+		if c.Settings["chat_id"] == "" || c.Settings["chat_id"] == "0" {
+			errs = append(errs, errors.New("settings.chat_id is required for a telegram channel"))
+		}
+	case ChannelSlack:
+		if err := validateURL(c.Settings["webhook_url"]); err != nil {
+			errs = append(errs, fmt.Errorf("settings.webhook_url: %w", err))
+		}
+	case ChannelWebhook:
+		if err := validateURL(c.Settings["url"]); err != nil {
+			errs = append(errs, fmt.Errorf("settings.url: %w", err))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown channel type %q", c.Type))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("a valid http(s) URL is required")
+	}
+	return nil
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(a, b []string) bool {
+	for _, v := range a {
+		if contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
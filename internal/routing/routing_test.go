@@ -0,0 +1,149 @@
+package routing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"site-monitor/internal/storage"
+)
+
+func TestMatches(t *testing.T) {
+	// 2026-10-17 is a Saturday.
+	at := func(hh, mm int) time.Time { return time.Date(2026, 10, 17, hh, mm, 0, 0, time.UTC) }
+	alert := func(t time.Time) Alert {
+		return Alert{Tags: []string{"prod", "payments"}, Team: "checkout", Severity: SeverityCritical, Time: t}
+	}
+
+	tests := []struct {
+		name string
+		r    storage.Route
+		a    Alert
+		want bool
+	}{
+		{"catch-all", storage.Route{}, alert(at(12, 0)), true},
+		{"one of the tags", storage.Route{Tags: []string{"staging", "payments"}}, alert(at(12, 0)), true},
+		{"no shared tag", storage.Route{Tags: []string{"staging"}}, alert(at(12, 0)), false},
+		{"team", storage.Route{Teams: []string{"checkout", "search"}}, alert(at(12, 0)), true},
+		{"other team", storage.Route{Teams: []string{"search"}}, alert(at(12, 0)), false},
+		{"severity", storage.Route{Severities: []string{SeverityCritical}}, alert(at(12, 0)), true},
+		{"other severity", storage.Route{Severities: []string{SeverityWarning, SeverityInfo}}, alert(at(12, 0)), false},
+		{"weekday", storage.Route{Weekdays: []int{6}, Timezone: "UTC"}, alert(at(12, 0)), true},
+		{"other weekday", storage.Route{Weekdays: []int{1, 2, 3, 4, 5}, Timezone: "UTC"}, alert(at(12, 0)), false},
+		{"office hours, inside", storage.Route{StartTime: "09:00", EndTime: "18:00", Timezone: "UTC"}, alert(at(9, 0)), true},
+		{"office hours, at end", storage.Route{StartTime: "09:00", EndTime: "18:00", Timezone: "UTC"}, alert(at(18, 0)), false},
+		{"night shift, before midnight", storage.Route{StartTime: "22:00", EndTime: "06:00", Timezone: "UTC"}, alert(at(23, 30)), true},
+		{"night shift, after midnight", storage.Route{StartTime: "22:00", EndTime: "06:00", Timezone: "UTC"}, alert(at(5, 59)), true},
+		{"night shift, daytime", storage.Route{StartTime: "22:00", EndTime: "06:00", Timezone: "UTC"}, alert(at(12, 0)), false},
+		{"in the route's time zone", storage.Route{StartTime: "09:00", EndTime: "18:00", Timezone: "Asia/Tokyo"}, alert(at(8, 0)), true},
+		{"weekday in the route's time zone", storage.Route{Weekdays: []int{0}, Timezone: "Asia/Tokyo"}, alert(at(20, 0)), true},
+		{"invalid time zone", storage.Route{Weekdays: []int{6}, Timezone: "Mars/Base"}, alert(at(12, 0)), false},
+		{"all criteria", storage.Route{Tags: []string{"prod"}, Teams: []string{"checkout"},
+			Severities: []string{SeverityCritical}, Weekdays: []int{6}, StartTime: "10:00", EndTime: "14:00",
+			Timezone: "UTC"}, alert(at(12, 0)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Matches(tt.r, tt.a); got != tt.want {
+				t.Errorf("Matches(%+v) = %t, want %t", tt.r, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	critical := storage.Route{ID: "critical", Severities: []string{SeverityCritical}}
+	critCont := storage.Route{ID: "critical-continue", Severities: []string{SeverityCritical}, Continue: true}
+	prod := storage.Route{ID: "prod", Tags: []string{"prod"}}
+	all := storage.Route{ID: "all"}
+
+	tests := []struct {
+		name   string
+		routes []storage.Route
+		a      Alert
+		want   []string
+	}{
+		{"first match stops", []storage.Route{critical, prod, all}, Alert{Severity: SeverityCritical, Tags: []string{"prod"}}, []string{"critical"}},
+		{"continue falls through", []storage.Route{critCont, prod, all}, Alert{Severity: SeverityCritical, Tags: []string{"prod"}}, []string{"critical-continue", "prod"}},
+		{"skips non-matching", []storage.Route{critical, prod, all}, Alert{Severity: SeverityWarning}, []string{"all"}},
+		{"no match", []storage.Route{critical, prod}, Alert{Severity: SeverityInfo}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range Resolve(tt.routes, tt.a) {
+				got = append(got, r.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRoute(t *testing.T) {
+	valid := storage.Route{Name: "on-call", ChannelIDs: []string{"c1"}, Timezone: "UTC"}
+
+	tests := []struct {
+		name    string
+		edit    func(*storage.Route)
+		wantErr string
+	}{
+		{"valid", func(*storage.Route) {}, ""},
+		{"no name", func(r *storage.Route) { r.Name = "" }, "name is required"},
+		{"no channels", func(r *storage.Route) { r.ChannelIDs = nil }, "at least one channel_id"},
+		{"unknown severity", func(r *storage.Route) { r.Severities = []string{"fatal"} }, `unknown severity "fatal"`},
+		{"weekday out of range", func(r *storage.Route) { r.Weekdays = []int{7} }, "invalid weekday 7"},
+		{"start without end", func(r *storage.Route) { r.StartTime = "09:00" }, "must be set together"},
+		{"bad time", func(r *storage.Route) { r.StartTime, r.EndTime = "9am", "18:00" }, `invalid time "9am"`},
+		{"bad time zone", func(r *storage.Route) { r.Timezone = "Mars/Base" }, `invalid timezone "Mars/Base"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid
+			tt.edit(&r)
+			err := ValidateRoute(r)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateRoute: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateRoute error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       storage.NotificationChannel
+		wantErr string
+	}{
+		{"telegram", storage.NotificationChannel{Name: "ops", Type: ChannelTelegram, Settings: map[string]string{"chat_id": "-100123"}}, ""},
+		{"telegram without chat", storage.NotificationChannel{Name: "ops", Type: ChannelTelegram, Settings: map[string]string{"chat_id": "0"}}, "settings.chat_id"},
+		{"slack", storage.NotificationChannel{Name: "ops", Type: ChannelSlack, Settings: map[string]string{"webhook_url": "https://hooks.slack.com/x"}}, ""},
+		{"slack without scheme", storage.NotificationChannel{Name: "ops", Type: ChannelSlack, Settings: map[string]string{"webhook_url": "hooks.slack.com/x"}}, "settings.webhook_url"},
+		{"webhook", storage.NotificationChannel{Name: "ops", Type: ChannelWebhook, Settings: map[string]string{"url": "http://hooks:8080/alert"}}, ""},
+		{"webhook ftp", storage.NotificationChannel{Name: "ops", Type: ChannelWebhook, Settings: map[string]string{"url": "ftp://hooks/alert"}}, "settings.url"},
+		{"unknown type", storage.NotificationChannel{Name: "ops", Type: "email"}, `unknown channel type "email"`},
+		{"no name", storage.NotificationChannel{Type: ChannelWebhook, Settings: map[string]string{"url": "http://hooks/alert"}}, "name is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateChannel(tt.c)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateChannel: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateChannel error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
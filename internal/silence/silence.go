@@ -0,0 +1,65 @@
+package silence
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"site-monitor/internal/storage"
+)
+
+func Validate(s storage.Silence, now time.Time) error {
+	var errs []error
+
+	if s.SiteID == "" && s.Tag == "" && s.URLPattern == "" {
+		errs = append(errs, errors.New("at least one of site_id, tag or url_pattern is required"))
+	}
+	if s.ExpiresAt.IsZero() {
+		errs = append(errs, errors.New("expires_at is required"))
+	} else if !s.ExpiresAt.After(now) {
+		errs = append(errs, errors.New("expires_at must be in the future"))
+	}
+	if strings.TrimSpace(s.Comment) == "" {
+		errs = append(errs, errors.New("comment is required"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func Matches(s storage.Silence, siteID, url string, tags []string) bool {
+	if s.SiteID == "" && s.Tag == "" && s.URLPattern == "" {
+		return false
+	}
+	if s.SiteID != "" && s.SiteID != siteID {
+		return false
+	}
+	if s.Tag != "" && !containsTag(tags, s.Tag) {
+		return false
+	}
+	if s.URLPattern != "" && !globMatch(s.URLPattern, url) {
+		return false
+	}
+	return true
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func globMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	re, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
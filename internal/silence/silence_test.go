@@ -0,0 +1,79 @@
+package silence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"site-monitor/internal/storage"
+)
+
+func TestMatches(t *testing.T) {
+	const (
+		site = "2a7d4b1c-8e5f-4f6a-9c3b-1d2e3f4a5b6c"
+		url  = "https://shop.example.com/checkout"
+	)
+	tags := []string{"prod", "payments"}
+
+	tests := []struct {
+		name string
+		s    storage.Silence
+		want bool
+	}{
+		{"empty silence matches nothing", storage.Silence{}, false},
+		{"site", storage.Silence{SiteID: site}, true},
+		{"other site", storage.Silence{SiteID: "other"}, false},
+		{"tag", storage.Silence{Tag: "payments"}, true},
+		{"missing tag", storage.Silence{Tag: "staging"}, false},
+		{"tag is case-sensitive", storage.Silence{Tag: "Prod"}, false},
+		{"exact url", storage.Silence{URLPattern: url}, true},
+		{"url prefix glob", storage.Silence{URLPattern: "https://shop.example.com/*"}, true},
+		{"url infix glob", storage.Silence{URLPattern: "https://*.example.com/*"}, true},
+		{"url glob anchored", storage.Silence{URLPattern: "shop.example.com/*"}, false},
+		{"url without glob is not a prefix", storage.Silence{URLPattern: "https://shop.example.com"}, false},
+		{"regexp metacharacters are literal", storage.Silence{URLPattern: "https://shop.example.com/check.ut"}, false},
+		{"all criteria", storage.Silence{SiteID: site, Tag: "prod", URLPattern: "*/checkout"}, true},
+		{"all criteria, tag misses", storage.Silence{SiteID: site, Tag: "staging", URLPattern: "*/checkout"}, false},
+		{"all criteria, url misses", storage.Silence{SiteID: site, Tag: "prod", URLPattern: "*/cart"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Matches(tt.s, site, url, tags); got != tt.want {
+				t.Errorf("Matches(%+v) = %t, want %t", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	valid := storage.Silence{Tag: "prod", ExpiresAt: now.Add(time.Hour), Comment: "deploy"}
+
+	tests := []struct {
+		name    string
+		edit    func(*storage.Silence)
+		wantErr string
+	}{
+		{"valid", func(*storage.Silence) {}, ""},
+		{"no matcher", func(s *storage.Silence) { s.Tag = "" }, "at least one of site_id, tag or url_pattern"},
+		{"no expiry", func(s *storage.Silence) { s.ExpiresAt = time.Time{} }, "expires_at is required"},
+		{"expired", func(s *storage.Silence) { s.ExpiresAt = now }, "expires_at must be in the future"},
+		{"blank comment", func(s *storage.Silence) { s.Comment = "  " }, "comment is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.edit(&s)
+			err := Validate(s, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package sites
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"site-monitor/internal/storage"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Formats lists what Decode and Encode accept.
+var Formats = []string{FormatCSV, FormatYAML, FormatJSON}
+
+// csvColumns is the header Encode writes. Decode accepts any order and
+// subset of it as long as url is there. Tags are separated by ";", labels
+// are written as "key=value;key=value".
+var csvColumns = []string{"id", "external_id", "url", "name", "description", "owner", "runbook_url", "active", "team", "tags", "labels"}
+
+// Record is a site as it appears in an import or export file. Active is a
+// pointer so that rows which don't mention it import as active.
+type Record struct {
+	ID          string            `json:"id,omitempty" yaml:"id,omitempty"`
+	ExternalID  string            `json:"external_id,omitempty" yaml:"external_id,omitempty"`
+	URL         string            `json:"url" yaml:"url"`
+	Name        string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Owner       string            `json:"owner,omitempty" yaml:"owner,omitempty"`
+	RunbookURL  string            `json:"runbook_url,omitempty" yaml:"runbook_url,omitempty"`
+	Active      *bool             `json:"active,omitempty" yaml:"active,omitempty"`
+	Team        string            `json:"team,omitempty" yaml:"team,omitempty"`
+	Tags        []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+func (r Record) Site() storage.Site {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return storage.Site{
+		ExternalID: r.ExternalID, URL: r.URL, Active: active, Tags: r.Tags, Team: r.Team,
+		Name: r.Name, Description: r.Description, Owner: r.Owner, RunbookURL: r.RunbookURL, Labels: r.Labels,
+	}
+}
+
+func recordOf(s storage.Site) Record {
+	active := s.Active
+	return Record{
+		ID: s.ID, ExternalID: s.ExternalID, URL: s.URL, Name: s.Name, Description: s.Description, Owner: s.Owner,
+		RunbookURL: s.RunbookURL, Active: &active, Team: s.Team, Tags: s.Tags, Labels: s.Labels,
+	}
+}
+
+// Row is one decoded entry of an import file. Err is set when the entry
+// itself is malformed; the rest of the file is still decoded.
+type Row struct {
+	// Row numbers start at 1 and don't count the CSV header.
+	Row  int
+	Site storage.Site
+	Err  error
+}
+
+// Decode reads an import file. It fails only when the file as a whole can't
+// be read; problems with single rows are reported in Row.Err.
+func Decode(format string, r io.Reader) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatYAML, FormatJSON:
+		var records []Record
+		var err error
+		if format == FormatYAML {
+			err = yaml.NewDecoder(r).Decode(&records)
+		} else {
+			err = json.NewDecoder(r).Decode(&records)
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("expected a list of sites: %w", err)
+		}
+		rows := make([]Row, len(records))
+		for i, rec := range records {
+			rows[i] = Row{Row: i + 1, Site: rec.Site()}
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func decodeCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, col := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(col))
+		if !slices.Contains(csvColumns, header[i]) {
+			return nil, fmt.Errorf("unknown column %q, expected some of %s", col, strings.Join(csvColumns, ", "))
+		}
+	}
+	if !slices.Contains(header, "url") {
+		return nil, errors.New("the url column is required")
+	}
+
+	var rows []Row
+	for n := 1; ; n++ {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rows = append(rows, Row{Row: n, Err: parseErr.Err})
+			continue
+		}
+		if len(fields) != len(header) {
+			rows = append(rows, Row{Row: n, Err: fmt.Errorf("expected %d fields, got %d", len(header), len(fields))})
+			continue
+		}
+
+		rec, err := csvRecord(header, fields)
+		rows = append(rows, Row{Row: n, Site: rec.Site(), Err: err})
+	}
+}
+
+func csvRecord(header, fields []string) (Record, error) {
+	var rec Record
+	for i, col := range header {
+		v := strings.TrimSpace(fields[i])
+		switch col {
+		case "external_id":
+			rec.ExternalID = v
+		case "url":
+			rec.URL = v
+		case "name":
+			rec.Name = v
+		case "description":
+			rec.Description = v
+		case "owner":
+			rec.Owner = v
+		case "runbook_url":
+			rec.RunbookURL = v
+		case "team":
+			rec.Team = v
+		case "active":
+			if v == "" {
+				continue
+			}
+			active, err := strconv.ParseBool(v)
+			if err != nil {
+				return rec, fmt.Errorf("active must be true or false, got %q", v)
+			}
+			rec.Active = &active
+		case "tags":
+			for _, t := range strings.Split(v, ";") {
+				if t = strings.TrimSpace(t); t != "" {
+					rec.Tags = append(rec.Tags, t)
+				}
+			}
+		case "labels":
+			for _, pair := range strings.Split(v, ";") {
+				if pair = strings.TrimSpace(pair); pair == "" {
+					continue
+				}
+				k, val, ok := strings.Cut(pair, "=")
+				if !ok {
+					return rec, fmt.Errorf("label %q must be key=value", pair)
+				}
+				if rec.Labels == nil {
+					rec.Labels = map[string]string{}
+				}
+				rec.Labels[strings.TrimSpace(k)] = strings.TrimSpace(val)
+			}
+		}
+	}
+	return rec, nil
+}
+
+// Encode writes sites in format, in a shape Decode reads back.
+func Encode(format string, w io.Writer, sites []storage.Site) error {
+	records := make([]Record, len(sites))
+	for i, s := range sites {
+		records[i] = recordOf(s)
+	}
+
+	switch format {
+	case FormatCSV:
+		return encodeCSV(w, records)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(records); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func encodeCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		labels := make([]string, 0, len(rec.Labels))
+		for _, k := range slices.Sorted(maps.Keys(rec.Labels)) {
+			labels = append(labels, k+"="+rec.Labels[k])
+		}
+		err := cw.Write([]string{
+			rec.ID, rec.ExternalID, rec.URL, rec.Name, rec.Description, rec.Owner, rec.RunbookURL,
+			strconv.FormatBool(*rec.Active), rec.Team, strings.Join(rec.Tags, ";"), strings.Join(labels, ";"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ContentType is the media type a file of format is served as.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatYAML:
+		return "application/yaml"
+	default:
+		return "application/json"
+	}
+}
+
+// FormatOf maps an import's media type to its format, or "" if unknown.
+func FormatOf(mediaType string) string {
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return FormatYAML
+	case "application/json":
+		return FormatJSON
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,108 @@
+package sites
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"site-monitor/internal/storage"
+)
+
+// Normalize brings the site URL to the form it is stored and compared in, so
+// that "Example.com/" and "https://example.com:443" are the same site. URLs
+// that don't parse are left alone for Validate to reject. Metadata fields
+// lose surrounding whitespace.
+func Normalize(s *storage.Site) {
+	if u, err := NormalizeURL(s.URL); err == nil {
+		s.URL = u
+	}
+	s.Name = strings.TrimSpace(s.Name)
+	s.Owner = strings.TrimSpace(s.Owner)
+	s.ExternalID = strings.TrimSpace(s.ExternalID)
+	s.RunbookURL = strings.TrimSpace(s.RunbookURL)
+}
+
+// labelKey allows the characters Kubernetes allows in label keys, so labels
+// can be copied from there.
+var labelKey = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_./-]{0,62})$`)
+
+const maxLabelValue = 255
+
+var externalID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:/-]{0,127}$`)
+
+func Validate(s storage.Site) error {
+	var errs []error
+
+	if s.URL == "" {
+		errs = append(errs, errors.New("url is required"))
+	} else if _, err := NormalizeURL(s.URL); err != nil {
+		errs = append(errs, err)
+	}
+	if s.ExternalID != "" && !externalID.MatchString(s.ExternalID) {
+		errs = append(errs, errors.New("external_id is up to 128 letters, digits, '_', '.', ':', '/' or '-'"))
+	}
+	if s.RunbookURL != "" {
+		if u, err := url.Parse(s.RunbookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.New("runbook_url must be an http(s) URL"))
+		}
+	}
+	for k, v := range s.Labels {
+		if !labelKey.MatchString(k) {
+			errs = append(errs, fmt.Errorf("label %q: keys are up to 63 letters, digits, '_', '.', '/' or '-'", k))
+		}
+		if len(v) > maxLabelValue {
+			errs = append(errs, fmt.Errorf("label %q: value is longer than %d bytes", k, maxLabelValue))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NormalizeURL defaults the scheme to https, lowercases scheme and host,
+// drops default ports, the fragment and a trailing slash.
+func NormalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("url is required")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("url scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.User != nil {
+		return "", errors.New("url must not contain credentials")
+	}
+
+	host, port := u.Hostname(), u.Port()
+	if host == "" {
+		return "", errors.New("url must have a host")
+	}
+	host = strings.ToLower(host)
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return u.String(), nil
+}
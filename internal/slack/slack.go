@@ -0,0 +1,38 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type Client struct {
+	webhookURL string
+}
+
+func NewClient(webhookURL string) *Client {
+	return &Client{webhookURL: webhookURL}
+}
+
+func (c *Client) Name() string {
+	return "slack"
+}
+
+func (c *Client) SendMessage(message string) error {
+	body, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(c.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook error: %s", resp.Status)
+	}
+	return nil
+}
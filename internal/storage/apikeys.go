@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const apiKeyColumns = `id, org_id, name, prefix, key_hash, role, created_at, revoked_at`
+
+// AddAPIKey binds the key to orgID. Keys created with AllOrgs are
+// platform-wide and act across every tenant.
+func (p *PostgresStorage) AddAPIKey(ctx context.Context, orgID string, k APIKey) (string, error) {
+	if k.ID == "" {
+		k.ID = uuid.New().String()
+	}
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO api_keys (id, org_id, name, prefix, key_hash, role) VALUES ($1, $2, $3, $4, $5, $6)`,
+		k.ID, orgArg(orgID), k.Name, k.Prefix, k.Hash, k.Role,
+	)
+	if err != nil {
+		return "", err
+	}
+	return k.ID, nil
+}
+
+func (p *PostgresStorage) GetAPIKeys(ctx context.Context, orgID string) ([]APIKey, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE `+orgCond(1)+` ORDER BY created_at`, orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// GetAPIKeyByHash returns only keys that haven't been revoked. It isn't
+// scoped because the key itself is what tells which tenant the caller is.
+func (p *PostgresStorage) GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	k, err := scanAPIKey(p.db.QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash=$1 AND revoked_at IS NULL`, hash,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (p *PostgresStorage) RevokeAPIKey(ctx context.Context, orgID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL AND `+orgCond(2), id, orgArg(orgID),
+	))
+}
+
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var k APIKey
+	var orgID sql.NullString
+	if err := row.Scan(&k.ID, &orgID, &k.Name, &k.Prefix, &k.Hash, &k.Role, &k.CreatedAt, &k.RevokedAt); err != nil {
+		return nil, err
+	}
+	k.OrgID = orgID.String
+	return &k, nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const auditColumns = `id, org_id, actor, action, resource_type, resource_id, before, after, changes,
+	request_id, source_ip, created_at`
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+func (p *PostgresStorage) AddAuditEntry(ctx context.Context, orgID string, e AuditEntry) error {
+	if err := requireOrg(orgID); err != nil {
+		return err
+	}
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	changes, err := json.Marshal(nonNilChanges(e.Changes))
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx,
+		`INSERT INTO audit_log (id, org_id, actor, action, resource_type, resource_id, before, after, changes,
+			request_id, source_ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		e.ID, orgID, e.Actor, e.Action, e.ResourceType, e.ResourceID, nullJSON(e.Before), nullJSON(e.After),
+		changes, e.RequestID, e.SourceIP, e.CreatedAt,
+	)
+	return err
+}
+
+// GetAuditEntries returns the newest entries first. Empty filter fields
+// match everything.
+func (p *PostgresStorage) GetAuditEntries(ctx context.Context, orgID string, f AuditFilter) ([]AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE ` + orgCond(1)
+	args := []any{orgArg(orgID)}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		query += ` AND ` + cond + `$` + strconv.Itoa(len(args))
+	}
+	if f.Actor != "" {
+		add(`actor=`, f.Actor)
+	}
+	if f.Action != "" {
+		add(`action=`, f.Action)
+	}
+	if f.ResourceType != "" {
+		add(`resource_type=`, f.ResourceType)
+	}
+	if f.ResourceID != "" {
+		add(`resource_id=`, f.ResourceID)
+	}
+	if f.Since != nil {
+		add(`created_at>=`, *f.Since)
+	}
+	if f.Until != nil {
+		add(`created_at<`, *f.Until)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	args = append(args, min(limit, maxAuditLimit))
+	query += ` ORDER BY created_at DESC LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func scanAuditEntry(row rowScanner) (*AuditEntry, error) {
+	var e AuditEntry
+	var before, after, changes []byte
+	err := row.Scan(&e.ID, &e.OrgID, &e.Actor, &e.Action, &e.ResourceType, &e.ResourceID, &before, &after,
+		&changes, &e.RequestID, &e.SourceIP, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	e.Before, e.After = before, after
+	if err := json.Unmarshal(changes, &e.Changes); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func nullJSON(b json.RawMessage) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return []byte(b)
+}
+
+func nonNilChanges(c map[string]AuditChange) map[string]AuditChange {
+	if c == nil {
+		return map[string]AuditChange{}
+	}
+	return c
+}
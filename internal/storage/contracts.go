@@ -1,17 +1,372 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
 
 type Site struct {
-	ID     string `json:"id"`
-	URL    string `json:"url"`
-	Active bool   `json:"active"`
+	ID     string   `json:"id"`
+	OrgID  string   `json:"org_id"`
+	URL    string   `json:"url"`
+	Active bool     `json:"active"`
+	Tags   []string `json:"tags"`
+	Team   string   `json:"team"`
+
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	RunbookURL  string `json:"runbook_url"`
+	// Labels are free-form key/value pairs such as env=prod. They travel with
+	// check results to alerts, and allowlisted keys become Prometheus labels.
+	Labels map[string]string `json:"labels"`
+	// ExternalID is a caller-chosen key, unique within the organization, that
+	// survives URL changes. Monitors-as-code uses it to track its sites.
+	ExternalID string `json:"external_id"`
+
+	// Version starts at 1 and grows with every update; it is the site's ETag.
+	Version int64 `json:"version"`
+}
+
+type MaintenanceWindow struct {
+	ID              string     `json:"id"`
+	OrgID           string     `json:"org_id"`
+	Name            string     `json:"name"`
+	SiteIDs         []string   `json:"site_ids"`
+	Tags            []string   `json:"tags"`
+	Suppress        string     `json:"suppress"`
+	ScheduleType    string     `json:"schedule_type"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	EndsAt          *time.Time `json:"ends_at,omitempty"`
+	Cron            string     `json:"cron,omitempty"`
+	Weekdays        []int      `json:"weekdays,omitempty"`
+	StartTime       string     `json:"start_time,omitempty"`
+	DurationMinutes int        `json:"duration_minutes,omitempty"`
+	Timezone        string     `json:"timezone"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastActiveAt    *time.Time `json:"last_active_at,omitempty"`
+	SummarySentAt   *time.Time `json:"summary_sent_at,omitempty"`
+}
+
+type Silence struct {
+	ID         string    `json:"id"`
+	OrgID      string    `json:"org_id"`
+	SiteID     string    `json:"site_id,omitempty"`
+	Tag        string    `json:"tag,omitempty"`
+	URLPattern string    `json:"url_pattern,omitempty"`
+	Comment    string    `json:"comment"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type Incident struct {
+	ID             string     `json:"id"`
+	OrgID          string     `json:"org_id"`
+	SiteID         string     `json:"site_id,omitempty"`
+	URL            string     `json:"url"`
+	Status         string     `json:"status"`
+	OpenedAt       time.Time  `json:"opened_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	AckComment     string     `json:"ack_comment,omitempty"`
+
+	EscalationPolicyID string     `json:"escalation_policy_id,omitempty"`
+	EscalationLevel    int        `json:"escalation_level"`
+	NextEscalationAt   *time.Time `json:"next_escalation_at,omitempty"`
+}
+
+// CheckResult is one check of a site as the alert service received it. Up
+// follows the alerting rule: a site is up when it answered 200.
+type CheckResult struct {
+	SiteID         string    `json:"site_id,omitempty"`
+	OrgID          string    `json:"org_id"`
+	URL            string    `json:"url"`
+	Status         int       `json:"status"`
+	Up             bool      `json:"up"`
+	ResponseTimeMs int       `json:"response_time_ms"`
+	Error          string    `json:"error,omitempty"`
+	Maintenance    bool      `json:"maintenance"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+type SuppressedEvent struct {
+	ID                  string    `json:"id"`
+	OrgID               string    `json:"org_id"`
+	SiteID              string    `json:"site_id,omitempty"`
+	URL                 string    `json:"url"`
+	Status              int       `json:"status"`
+	Reason              string    `json:"reason"`
+	SilenceID           string    `json:"silence_id,omitempty"`
+	MaintenanceWindowID string    `json:"maintenance_window_id,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type OnCallParticipant struct {
+	Name   string `json:"name"`
+	ChatID string `json:"chat_id"`
+}
+
+type OnCallSchedule struct {
+	ID            string              `json:"id"`
+	OrgID         string              `json:"org_id"`
+	Name          string              `json:"name"`
+	Team          string              `json:"team"`
+	Timezone      string              `json:"timezone"`
+	RotationStart time.Time           `json:"rotation_start"`
+	Participants  []OnCallParticipant `json:"participants"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+type OnCallOverride struct {
+	ID         string    `json:"id"`
+	ScheduleID string    `json:"schedule_id"`
+	Name       string    `json:"name"`
+	ChatID     string    `json:"chat_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+}
+
+type EscalationLevel struct {
+	EscalateAfterMinutes int      `json:"escalate_after_minutes"`
+	ScheduleID           string   `json:"schedule_id,omitempty"`
+	ChatIDs              []string `json:"chat_ids,omitempty"`
+}
+
+type EscalationPolicy struct {
+	ID        string            `json:"id"`
+	OrgID     string            `json:"org_id"`
+	Name      string            `json:"name"`
+	Team      string            `json:"team"`
+	Levels    []EscalationLevel `json:"levels"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type NotificationChannel struct {
+	ID        string            `json:"id"`
+	OrgID     string            `json:"org_id"`
+	Name      string            `json:"name"`
+	Type      string            `json:"type"`
+	Settings  map[string]string `json:"settings"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type Route struct {
+	ID         string    `json:"id"`
+	OrgID      string    `json:"org_id"`
+	Name       string    `json:"name"`
+	Priority   int       `json:"priority"`
+	Tags       []string  `json:"tags"`
+	Teams      []string  `json:"teams"`
+	Severities []string  `json:"severities"`
+	Weekdays   []int     `json:"weekdays"`
+	StartTime  string    `json:"start_time,omitempty"`
+	EndTime    string    `json:"end_time,omitempty"`
+	Timezone   string    `json:"timezone"`
+	ChannelIDs []string  `json:"channel_ids"`
+	Continue   bool      `json:"continue"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// APIKey is stored by hash only; the plain key is shown once on creation.
+// An empty OrgID marks a platform-wide key.
+type APIKey struct {
+	ID        string     `json:"id"`
+	OrgID     string     `json:"org_id,omitempty"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
 }
 
+// AuditEntry records one change made through the API. Before is empty for
+// creations and After for deletions; Changes lists the top-level fields that
+// differ between the two.
+type AuditEntry struct {
+	ID           string                 `json:"id"`
+	OrgID        string                 `json:"org_id"`
+	Actor        string                 `json:"actor"`
+	Action       string                 `json:"action"`
+	ResourceType string                 `json:"resource_type"`
+	ResourceID   string                 `json:"resource_id"`
+	Before       json.RawMessage        `json:"before,omitempty"`
+	After        json.RawMessage        `json:"after,omitempty"`
+	Changes      map[string]AuditChange `json:"changes"`
+	RequestID    string                 `json:"request_id,omitempty"`
+	SourceIP     string                 `json:"source_ip,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// SiteFilter narrows and orders GetSites. Empty fields match everything.
+type SiteFilter struct {
+	Active *bool
+	// Tags must all be present on the site.
+	Tags []string
+	// Labels must all be set to the given values, LabelKeys only present.
+	Labels     map[string]string
+	LabelKeys  []string
+	ExternalID string
+	// CheckType is the probe protocol, which for HTTP checks is the URL
+	// scheme: "http" or "https".
+	CheckType   string
+	URLContains string
+
+	// Sort is one of SiteSortFields; Desc reverses it. Ties are broken by ID.
+	Sort string
+	Desc bool
+	// Cursor is SitePage.NextCursor of the previous page.
+	Cursor string
+	Limit  int
+}
+
+// SitePage is one page of GetSites. Total counts every site that matches
+// the filter, NextCursor is empty on the last page.
+type SitePage struct {
+	Sites      []Site
+	Total      int
+	NextCursor string
+}
+
+const (
+	ImportCreate = "create"
+	ImportUpdate = "update"
+)
+
+type ImportOptions struct {
+	// Update overwrites sites whose URL already exists instead of failing.
+	Update bool
+	// DryRun runs the whole import and rolls it back.
+	DryRun bool
+}
+
+// SiteImport is the outcome of one site of ImportSites. Site is the row as
+// written, Before the row it replaced; Err is set instead when the site
+// can't be imported.
+type SiteImport struct {
+	Action string
+	Site   Site
+	Before *Site
+	Err    error
+}
+
+type AuditFilter struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Since        *time.Time
+	Until        *time.Time
+	Limit        int
+}
+
+const (
+	IncidentOpen     = "open"
+	IncidentResolved = "resolved"
+)
+
+// Storage is scoped by tenant: every method that touches tenant data takes
+// the organization it acts on. Reads and updates accept AllOrgs for
+// platform-wide callers, inserts need a concrete organization.
+//
+// Updates and deletes that match no row return ErrNotFound; writes that hit
+// a unique constraint return ErrDuplicate. Site updates given a non-zero
+// version return ErrVersionConflict when the row has moved on. A cursor
+// that GetSites didn't issue for the same sort returns ErrInvalidCursor.
 type Storage interface {
-	AddSite(ctx context.Context, site Site) (string, error)
-	GetSites(ctx context.Context) ([]Site, error)
-	GetSiteByID(ctx context.Context, id string) (*Site, error)
-	UpdateSite(ctx context.Context, site Site) error
-	DeleteSite(ctx context.Context, id string) error
+	Ping(ctx context.Context) error
+
+	AddOrganization(ctx context.Context, o Organization) (string, error)
+	GetOrganizations(ctx context.Context) ([]Organization, error)
+	GetOrganizationByID(ctx context.Context, id string) (*Organization, error)
+
+	AddSite(ctx context.Context, orgID string, site Site) (string, error)
+	GetSites(ctx context.Context, orgID string, f SiteFilter) (*SitePage, error)
+	GetSiteByID(ctx context.Context, orgID, id string) (*Site, error)
+	UpdateSite(ctx context.Context, orgID string, site Site) (*Site, error)
+	PatchSite(ctx context.Context, orgID, id string, version int64, apply func(s *Site) error) (*Site, error)
+	ImportSites(ctx context.Context, orgID string, sites []Site, opts ImportOptions) ([]SiteImport, error)
+	DeleteSite(ctx context.Context, orgID, id string) error
+
+	AddMaintenanceWindow(ctx context.Context, orgID string, w MaintenanceWindow) (string, error)
+	GetMaintenanceWindows(ctx context.Context, orgID string) ([]MaintenanceWindow, error)
+	GetMaintenanceWindowByID(ctx context.Context, orgID, id string) (*MaintenanceWindow, error)
+	UpdateMaintenanceWindow(ctx context.Context, orgID string, w MaintenanceWindow) error
+	DeleteMaintenanceWindow(ctx context.Context, orgID, id string) error
+	MarkMaintenanceWindowsActive(ctx context.Context, orgID string, ids []string, at time.Time) ([]MaintenanceWindow, error)
+	GetPendingMaintenanceSummaries(ctx context.Context, orgID string) ([]MaintenanceWindow, error)
+	MarkMaintenanceSummarySent(ctx context.Context, orgID, id string, at time.Time) error
+
+	AddSilence(ctx context.Context, orgID string, s Silence) (string, error)
+	GetSilences(ctx context.Context, orgID string, activeOnly bool) ([]Silence, error)
+	GetSilenceByID(ctx context.Context, orgID, id string) (*Silence, error)
+	UpdateSilence(ctx context.Context, orgID string, s Silence) error
+	DeleteSilence(ctx context.Context, orgID, id string) error
+
+	OpenIncident(ctx context.Context, orgID string, inc Incident) (string, error)
+	ResolveIncident(ctx context.Context, orgID, url string) error
+	GetIncidents(ctx context.Context, orgID, status string) ([]Incident, error)
+	GetIncidentByID(ctx context.Context, orgID, id string) (*Incident, error)
+	AcknowledgeIncident(ctx context.Context, orgID, id, by, comment string) error
+
+	AddSuppressedEvent(ctx context.Context, orgID string, e SuppressedEvent) error
+	GetSuppressedEvents(ctx context.Context, orgID, silenceID string) ([]SuppressedEvent, error)
+
+	AddCheckResult(ctx context.Context, orgID string, r CheckResult) error
+	GetCheckResults(ctx context.Context, orgID, siteID string, limit int) ([]CheckResult, error)
+	DeleteCheckResultsBefore(ctx context.Context, before time.Time) (int64, error)
+
+	AddOnCallSchedule(ctx context.Context, orgID string, s OnCallSchedule) (string, error)
+	GetOnCallSchedules(ctx context.Context, orgID string) ([]OnCallSchedule, error)
+	GetOnCallScheduleByID(ctx context.Context, orgID, id string) (*OnCallSchedule, error)
+	UpdateOnCallSchedule(ctx context.Context, orgID string, s OnCallSchedule) error
+	DeleteOnCallSchedule(ctx context.Context, orgID, id string) error
+
+	AddOnCallOverride(ctx context.Context, orgID string, o OnCallOverride) (string, error)
+	GetOnCallOverrides(ctx context.Context, orgID, scheduleID string) ([]OnCallOverride, error)
+	DeleteOnCallOverride(ctx context.Context, orgID, scheduleID, id string) error
+
+	AddEscalationPolicy(ctx context.Context, orgID string, p EscalationPolicy) (string, error)
+	GetEscalationPolicies(ctx context.Context, orgID string) ([]EscalationPolicy, error)
+	GetEscalationPolicyByID(ctx context.Context, orgID, id string) (*EscalationPolicy, error)
+	GetEscalationPolicyByTeam(ctx context.Context, orgID, team string) (*EscalationPolicy, error)
+	UpdateEscalationPolicy(ctx context.Context, orgID string, p EscalationPolicy) error
+	DeleteEscalationPolicy(ctx context.Context, orgID, id string) error
+
+	StartEscalation(ctx context.Context, orgID, incidentID, policyID string, nextAt *time.Time) (bool, error)
+	ProcessDueEscalation(ctx context.Context, handle func(inc Incident) (*time.Time, error)) (bool, error)
+
+	AddChannel(ctx context.Context, orgID string, c NotificationChannel) (string, error)
+	GetChannels(ctx context.Context, orgID string) ([]NotificationChannel, error)
+	GetChannelByID(ctx context.Context, orgID, id string) (*NotificationChannel, error)
+	UpdateChannel(ctx context.Context, orgID string, c NotificationChannel) error
+	DeleteChannel(ctx context.Context, orgID, id string) error
+
+	AddRoute(ctx context.Context, orgID string, r Route) (string, error)
+	GetRoutes(ctx context.Context, orgID string) ([]Route, error)
+	GetRouteByID(ctx context.Context, orgID, id string) (*Route, error)
+	UpdateRoute(ctx context.Context, orgID string, r Route) error
+	DeleteRoute(ctx context.Context, orgID, id string) error
+
+	AddAPIKey(ctx context.Context, orgID string, k APIKey) (string, error)
+	GetAPIKeys(ctx context.Context, orgID string) ([]APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, orgID, id string) error
+
+	AddAuditEntry(ctx context.Context, orgID string, e AuditEntry) error
+	GetAuditEntries(ctx context.Context, orgID string, f AuditFilter) ([]AuditEntry, error)
 }
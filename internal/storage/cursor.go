@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SiteSortURL  = "url"
+	SiteSortTeam = "team"
+
+	defaultSiteLimit = 100
+	maxSiteLimit     = 1000
+)
+
+// SiteSortFields lists the values SiteFilter.Sort accepts.
+var SiteSortFields = []string{SiteSortURL, SiteSortTeam}
+
+var siteSortColumns = map[string]string{
+	SiteSortURL:  "url",
+	SiteSortTeam: "team",
+}
+
+// siteCursor is the position after the last site of a page. It carries the
+// sort it was issued for, since the same position means nothing in another
+// order.
+type siteCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func siteSortValue(s Site, sort string) string {
+	if sort == SiteSortTeam {
+		return s.Team
+	}
+	return s.URL
+}
+
+func encodeSiteCursor(c siteCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSiteCursor(s string) (siteCursor, error) {
+	var c siteCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, err
+	}
+	_, err = uuid.Parse(c.ID)
+	return c, err
+}
+
+// parseSiteCursor decodes a cursor for the given sort and returns
+// ErrInvalidCursor for anything else, including cursors of another sort.
+func parseSiteCursor(s, sort string, desc bool) (siteCursor, error) {
+	c, err := decodeSiteCursor(s)
+	if err != nil || c.Sort != sort || c.Desc != desc {
+		return siteCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestParseSiteCursor(t *testing.T) {
+	valid := siteCursor{Sort: SiteSortURL, Value: "https://a.example", ID: "6f1c2b9e-8a4d-4c39-9b1e-2f7d8c5a4e10"}
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+		desc   bool
+	}{
+		{"other sort", encodeSiteCursor(valid), SiteSortTeam, false},
+		{"other direction", encodeSiteCursor(valid), SiteSortURL, true},
+		{"not base64", "not a cursor!", SiteSortURL, false},
+		{"not json", raw("url|a|6f1c2b9e"), SiteSortURL, false},
+		{"missing id", raw(`{"s":"url","v":"a"}`), SiteSortURL, false},
+		{"id is not a uuid", raw(`{"s":"url","v":"a","id":"1 OR 1=1"}`), SiteSortURL, false},
+		{"wrong types", raw(`{"s":1,"v":"a","id":"6f1c2b9e-8a4d-4c39-9b1e-2f7d8c5a4e10"}`), SiteSortURL, false},
+		{"unknown sort", raw(`{"s":"name","v":"a","id":"6f1c2b9e-8a4d-4c39-9b1e-2f7d8c5a4e10"}`), SiteSortURL, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSiteCursor(tt.cursor, tt.sort, tt.desc); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("parseSiteCursor(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
+
+func TestParseSiteCursorRoundTrip(t *testing.T) {
+	for _, c := range []siteCursor{
+		{Sort: SiteSortURL, Value: "https://a.example/?q='; DROP TABLE sites", ID: "6f1c2b9e-8a4d-4c39-9b1e-2f7d8c5a4e10"},
+		{Sort: SiteSortTeam, Desc: true, Value: "", ID: "00000000-0000-0000-0000-000000000001"},
+	} {
+		got, err := parseSiteCursor(encodeSiteCursor(c), c.Sort, c.Desc)
+		if err != nil {
+			t.Fatalf("parseSiteCursor(%+v): %v", c, err)
+		}
+		if got != c {
+			t.Errorf("round trip = %+v, want %+v", got, c)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotFound  = errors.New("storage: not found")
+	ErrDuplicate = errors.New("storage: already exists")
+
+	ErrVersionConflict = errors.New("storage: version conflict")
+	ErrInvalidCursor   = errors.New("storage: invalid cursor")
+)
+
+const pqUniqueViolation = "23505"
+
+// affected turns an update or delete that matched no rows into ErrNotFound.
+func affected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// duplicate maps unique constraint violations to ErrDuplicate.
+func duplicate(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+		return ErrDuplicate
+	}
+	return err
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const policyColumns = `id, org_id, name, team, levels, created_at`
+
+func (p *PostgresStorage) AddEscalationPolicy(ctx context.Context, orgID string, ep EscalationPolicy) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
+	if ep.ID == "" {
+		ep.ID = uuid.New().String()
+	}
+	levels, err := json.Marshal(nonNilLevels(ep.Levels))
+	if err != nil {
+		return "", err
+	}
+	_, err = p.db.ExecContext(ctx,
+		`INSERT INTO escalation_policies (id, org_id, name, team, levels) VALUES ($1, $2, $3, $4, $5)`,
+		ep.ID, orgID, ep.Name, ep.Team, levels,
+	)
+	if err != nil {
+		return "", duplicate(err)
+	}
+	return ep.ID, nil
+}
+
+func (p *PostgresStorage) GetEscalationPolicies(ctx context.Context, orgID string) ([]EscalationPolicy, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+policyColumns+` FROM escalation_policies WHERE `+orgCond(1)+` ORDER BY created_at`,
+		orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []EscalationPolicy
+	for rows.Next() {
+		ep, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *ep)
+	}
+	return policies, rows.Err()
+}
+
+func (p *PostgresStorage) GetEscalationPolicyByID(ctx context.Context, orgID, id string) (*EscalationPolicy, error) {
+	return p.getEscalationPolicy(ctx,
+		`SELECT `+policyColumns+` FROM escalation_policies WHERE id=$1 AND `+orgCond(2), id, orgID)
+}
+
+func (p *PostgresStorage) GetEscalationPolicyByTeam(ctx context.Context, orgID, team string) (*EscalationPolicy, error) {
+	return p.getEscalationPolicy(ctx,
+		`SELECT `+policyColumns+` FROM escalation_policies WHERE team=$1 AND `+orgCond(2), team, orgID)
+}
+
+func (p *PostgresStorage) getEscalationPolicy(ctx context.Context, query string, arg, orgID string) (*EscalationPolicy, error) {
+	ep, err := scanPolicy(p.db.QueryRowContext(ctx, query, arg, orgArg(orgID)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+func (p *PostgresStorage) UpdateEscalationPolicy(ctx context.Context, orgID string, ep EscalationPolicy) error {
+	levels, err := json.Marshal(nonNilLevels(ep.Levels))
+	if err != nil {
+		return err
+	}
+	return duplicate(affected(p.db.ExecContext(ctx,
+		`UPDATE escalation_policies SET name=$1, team=$2, levels=$3 WHERE id=$4 AND `+orgCond(5),
+		ep.Name, ep.Team, levels, ep.ID, orgArg(orgID),
+	)))
+}
+
+func (p *PostgresStorage) DeleteEscalationPolicy(ctx context.Context, orgID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`DELETE FROM escalation_policies WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+}
+
+func (p *PostgresStorage) StartEscalation(ctx context.Context, orgID, incidentID, policyID string, nextAt *time.Time) (bool, error) {
+	res, err := p.db.ExecContext(ctx,
+		`UPDATE incidents SET escalation_policy_id=$1, escalation_level=0, next_escalation_at=$2
+		WHERE id=$3 AND status=$4 AND escalation_policy_id IS NULL AND `+orgCond(5),
+		policyID, nextAt, incidentID, IncidentOpen, orgArg(orgID),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// escalationRetryDelay is how long an incident whose escalation failed waits
+// before it is tried again, so it doesn't hold up the other due incidents.
+const escalationRetryDelay = time.Minute
+
+// ProcessDueEscalation works across all tenants; handle receives the incident
+// with its OrgID set and has to scope any further lookups to it. When handle
+// fails the incident is pushed back by escalationRetryDelay and processed is
+// still true, so the caller can go on with the next one.
+func (p *PostgresStorage) ProcessDueEscalation(ctx context.Context, handle func(inc Incident) (*time.Time, error)) (bool, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	inc, err := scanIncident(tx.QueryRowContext(ctx,
+		`SELECT `+incidentColumns+` FROM incidents
+		WHERE status=$1 AND acknowledged_at IS NULL AND next_escalation_at <= now()
+		ORDER BY next_escalation_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`, IncidentOpen,
+	))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	nextAt, err := handle(*inc)
+	if err != nil {
+		// Pushed back while the row is still locked, so another replica can't
+		// claim the incident in between.
+		_, retryErr := tx.ExecContext(ctx,
+			`UPDATE incidents SET next_escalation_at=$1 WHERE id=$2`,
+			time.Now().Add(escalationRetryDelay), inc.ID,
+		)
+		if retryErr == nil {
+			retryErr = tx.Commit()
+		}
+		if retryErr != nil {
+			return false, errors.Join(err, retryErr)
+		}
+		return true, fmt.Errorf("incident %s: %w", inc.ID, err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE incidents SET escalation_level=escalation_level+1, next_escalation_at=$1 WHERE id=$2`,
+		nextAt, inc.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func scanPolicy(row rowScanner) (*EscalationPolicy, error) {
+	var ep EscalationPolicy
+	var levels []byte
+	if err := row.Scan(&ep.ID, &ep.OrgID, &ep.Name, &ep.Team, &levels, &ep.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(levels, &ep.Levels); err != nil {
+		return nil, err
+	}
+	return &ep, nil
+}
+
+func nonNilLevels(l []EscalationLevel) []EscalationLevel {
+	if l == nil {
+		return []EscalationLevel{}
+	}
+	return l
+}
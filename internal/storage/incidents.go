@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const incidentColumns = `id, org_id, site_id, url, status, opened_at, resolved_at, acknowledged_at, acknowledged_by, ack_comment,
+	escalation_policy_id, escalation_level, next_escalation_at`
+
+func (p *PostgresStorage) OpenIncident(ctx context.Context, orgID string, inc Incident) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
+	if inc.ID == "" {
+		inc.ID = uuid.New().String()
+	}
+
+	var id string
+	err := p.db.QueryRowContext(ctx,
+		`INSERT INTO incidents (id, org_id, site_id, url, status) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (org_id, url) WHERE status = 'open' DO UPDATE SET url = EXCLUDED.url
+		RETURNING id`,
+		inc.ID, orgID, nullString(inc.SiteID), inc.URL, IncidentOpen,
+	).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (p *PostgresStorage) ResolveIncident(ctx context.Context, orgID, url string) error {
+	_, err := p.db.ExecContext(ctx,
+		`UPDATE incidents SET status=$1, resolved_at=now() WHERE url=$2 AND status=$3 AND `+orgCond(4),
+		IncidentResolved, url, IncidentOpen, orgArg(orgID),
+	)
+	return err
+}
+
+func (p *PostgresStorage) GetIncidents(ctx context.Context, orgID, status string) ([]Incident, error) {
+	query := `SELECT ` + incidentColumns + ` FROM incidents WHERE ` + orgCond(1)
+	args := []any{orgArg(orgID)}
+	if status != "" {
+		query += ` AND status=$2`
+		args = append(args, status)
+	}
+	query += ` ORDER BY opened_at DESC`
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, *inc)
+	}
+	return incidents, rows.Err()
+}
+
+func (p *PostgresStorage) GetIncidentByID(ctx context.Context, orgID, id string) (*Incident, error) {
+	inc, err := scanIncident(p.db.QueryRowContext(ctx,
+		`SELECT `+incidentColumns+` FROM incidents WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inc, nil
+}
+
+func (p *PostgresStorage) AcknowledgeIncident(ctx context.Context, orgID, id, by, comment string) error {
+	_, err := p.db.ExecContext(ctx,
+		`UPDATE incidents SET acknowledged_at=now(), acknowledged_by=$1, ack_comment=$2
+		WHERE id=$3 AND status=$4 AND `+orgCond(5),
+		by, comment, id, IncidentOpen, orgArg(orgID),
+	)
+	return err
+}
+
+func (p *PostgresStorage) AddSuppressedEvent(ctx context.Context, orgID string, e SuppressedEvent) error {
+	if err := requireOrg(orgID); err != nil {
+		return err
+	}
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO suppressed_events (id, org_id, site_id, url, status, reason, silence_id, maintenance_window_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		e.ID, orgID, nullString(e.SiteID), e.URL, e.Status, e.Reason, nullString(e.SilenceID),
+		nullString(e.MaintenanceWindowID),
+	)
+	return err
+}
+
+func (p *PostgresStorage) GetSuppressedEvents(ctx context.Context, orgID, silenceID string) ([]SuppressedEvent, error) {
+	query := `SELECT id, org_id, site_id, url, status, reason, silence_id, maintenance_window_id, created_at
+		FROM suppressed_events WHERE ` + orgCond(1)
+	args := []any{orgArg(orgID)}
+	if silenceID != "" {
+		query += ` AND silence_id=$2`
+		args = append(args, silenceID)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []SuppressedEvent
+	for rows.Next() {
+		var e SuppressedEvent
+		var siteID, silence, window sql.NullString
+		if err := rows.Scan(&e.ID, &e.OrgID, &siteID, &e.URL, &e.Status, &e.Reason, &silence, &window, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.SiteID, e.SilenceID, e.MaintenanceWindowID = siteID.String, silence.String, window.String
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func scanIncident(row rowScanner) (*Incident, error) {
+	var inc Incident
+	var siteID, policyID sql.NullString
+	err := row.Scan(&inc.ID, &inc.OrgID, &siteID, &inc.URL, &inc.Status, &inc.OpenedAt, &inc.ResolvedAt,
+		&inc.AcknowledgedAt, &inc.AcknowledgedBy, &inc.AckComment,
+		&policyID, &inc.EscalationLevel, &inc.NextEscalationAt)
+	if err != nil {
+		return nil, err
+	}
+	inc.SiteID = siteID.String
+	inc.EscalationPolicyID = policyID.String
+	return &inc, nil
+}
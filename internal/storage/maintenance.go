@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const maintenanceColumns = `id, org_id, name, site_ids, tags, suppress, schedule_type, starts_at, ends_at,
+	cron, weekdays, start_time, duration_minutes, timezone, created_at, last_active_at, summary_sent_at`
+
+func (p *PostgresStorage) AddMaintenanceWindow(ctx context.Context, orgID string, w MaintenanceWindow) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO maintenance_windows (id, org_id, name, site_ids, tags, suppress, schedule_type, starts_at,
+			ends_at, cron, weekdays, start_time, duration_minutes, timezone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		w.ID, orgID, w.Name, pq.Array(nonNilStrings(w.SiteIDs)), pq.Array(nonNilStrings(w.Tags)), w.Suppress,
+		w.ScheduleType, w.StartsAt, w.EndsAt, w.Cron, pq.Array(toInt64s(w.Weekdays)), w.StartTime,
+		w.DurationMinutes, w.Timezone,
+	)
+	if err != nil {
+		return "", err
+	}
+	return w.ID, nil
+}
+
+func (p *PostgresStorage) GetMaintenanceWindows(ctx context.Context, orgID string) ([]MaintenanceWindow, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+maintenanceColumns+` FROM maintenance_windows WHERE `+orgCond(1)+` ORDER BY created_at`,
+		orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []MaintenanceWindow
+	for rows.Next() {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, *w)
+	}
+	return windows, rows.Err()
+}
+
+func (p *PostgresStorage) GetMaintenanceWindowByID(ctx context.Context, orgID, id string) (*MaintenanceWindow, error) {
+	w, err := scanMaintenanceWindow(p.db.QueryRowContext(ctx,
+		`SELECT `+maintenanceColumns+` FROM maintenance_windows WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (p *PostgresStorage) UpdateMaintenanceWindow(ctx context.Context, orgID string, w MaintenanceWindow) error {
+	return affected(p.db.ExecContext(ctx,
+		`UPDATE maintenance_windows SET name=$1, site_ids=$2, tags=$3, suppress=$4, schedule_type=$5,
+			starts_at=$6, ends_at=$7, cron=$8, weekdays=$9, start_time=$10, duration_minutes=$11, timezone=$12
+		WHERE id=$13 AND `+orgCond(14),
+		w.Name, pq.Array(nonNilStrings(w.SiteIDs)), pq.Array(nonNilStrings(w.Tags)), w.Suppress, w.ScheduleType,
+		w.StartsAt, w.EndsAt, w.Cron, pq.Array(toInt64s(w.Weekdays)), w.StartTime, w.DurationMinutes,
+		w.Timezone, w.ID, orgArg(orgID),
+	))
+}
+
+func (p *PostgresStorage) DeleteMaintenanceWindow(ctx context.Context, orgID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`DELETE FROM maintenance_windows WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+}
+
+// MarkMaintenanceWindowsActive returns, as they were before the update, the
+// windows that weren't active since their last summary.
+func (p *PostgresStorage) MarkMaintenanceWindowsActive(ctx context.Context, orgID string, ids []string, at time.Time) ([]MaintenanceWindow, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`WITH started AS (
+			SELECT `+maintenanceColumns+` FROM maintenance_windows
+			WHERE id = ANY($2::uuid[]) AND `+orgCond(3)+`
+				AND (last_active_at IS NULL OR summary_sent_at >= last_active_at)
+		), marked AS (
+			UPDATE maintenance_windows SET last_active_at=$1 WHERE id = ANY($2::uuid[]) AND `+orgCond(3)+`
+		)
+		SELECT `+maintenanceColumns+` FROM started ORDER BY created_at`,
+		at, pq.Array(ids), orgArg(orgID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []MaintenanceWindow
+	for rows.Next() {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, *w)
+	}
+	return windows, rows.Err()
+}
+
+// GetPendingMaintenanceSummaries returns the windows that were active since
+// their last summary. Some of them may still be active; telling those apart
+// is up to the caller.
+func (p *PostgresStorage) GetPendingMaintenanceSummaries(ctx context.Context, orgID string) ([]MaintenanceWindow, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+maintenanceColumns+` FROM maintenance_windows
+		WHERE last_active_at IS NOT NULL AND (summary_sent_at IS NULL OR summary_sent_at < last_active_at)
+			AND `+orgCond(1)+` ORDER BY last_active_at`,
+		orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []MaintenanceWindow
+	for rows.Next() {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, *w)
+	}
+	return windows, rows.Err()
+}
+
+func (p *PostgresStorage) MarkMaintenanceSummarySent(ctx context.Context, orgID, id string, at time.Time) error {
+	return affected(p.db.ExecContext(ctx,
+		`UPDATE maintenance_windows SET summary_sent_at=$1 WHERE id=$2 AND `+orgCond(3), at, id, orgArg(orgID),
+	))
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMaintenanceWindow(row rowScanner) (*MaintenanceWindow, error) {
+	var w MaintenanceWindow
+	var weekdays pq.Int64Array
+	err := row.Scan(&w.ID, &w.OrgID, &w.Name, pq.Array(&w.SiteIDs), pq.Array(&w.Tags), &w.Suppress, &w.ScheduleType,
+		&w.StartsAt, &w.EndsAt, &w.Cron, &weekdays, &w.StartTime, &w.DurationMinutes, &w.Timezone, &w.CreatedAt,
+		&w.LastActiveAt, &w.SummarySentAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range weekdays {
+		w.Weekdays = append(w.Weekdays, int(d))
+	}
+	return &w, nil
+}
+
+func toInt64s(in []int) []int64 {
+	out := make([]int64, 0, len(in))
+	for _, v := range in {
+		out = append(out, int64(v))
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const scheduleColumns = `id, org_id, name, team, timezone, rotation_start, participants, created_at`
+
+func (p *PostgresStorage) AddOnCallSchedule(ctx context.Context, orgID string, s OnCallSchedule) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	participants, err := json.Marshal(nonNilParticipants(s.Participants))
+	if err != nil {
+		return "", err
+	}
+	_, err = p.db.ExecContext(ctx,
+		`INSERT INTO on_call_schedules (id, org_id, name, team, timezone, rotation_start, participants)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		s.ID, orgID, s.Name, s.Team, s.Timezone, s.RotationStart, participants,
+	)
+	if err != nil {
+		return "", err
+	}
+	return s.ID, nil
+}
+
+func (p *PostgresStorage) GetOnCallSchedules(ctx context.Context, orgID string) ([]OnCallSchedule, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+scheduleColumns+` FROM on_call_schedules WHERE `+orgCond(1)+` ORDER BY created_at`,
+		orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []OnCallSchedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *s)
+	}
+	return schedules, rows.Err()
+}
+
+func (p *PostgresStorage) GetOnCallScheduleByID(ctx context.Context, orgID, id string) (*OnCallSchedule, error) {
+	s, err := scanSchedule(p.db.QueryRowContext(ctx,
+		`SELECT `+scheduleColumns+` FROM on_call_schedules WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *PostgresStorage) UpdateOnCallSchedule(ctx context.Context, orgID string, s OnCallSchedule) error {
+	participants, err := json.Marshal(nonNilParticipants(s.Participants))
+	if err != nil {
+		return err
+	}
+	return affected(p.db.ExecContext(ctx,
+		`UPDATE on_call_schedules SET name=$1, team=$2, timezone=$3, rotation_start=$4, participants=$5
+		WHERE id=$6 AND `+orgCond(7),
+		s.Name, s.Team, s.Timezone, s.RotationStart, participants, s.ID, orgArg(orgID),
+	))
+}
+
+func (p *PostgresStorage) DeleteOnCallSchedule(ctx context.Context, orgID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`DELETE FROM on_call_schedules WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+}
+
+func (p *PostgresStorage) AddOnCallOverride(ctx context.Context, orgID string, o OnCallOverride) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
+	if o.ID == "" {
+		o.ID = uuid.New().String()
+	}
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO on_call_overrides (id, org_id, schedule_id, name, chat_id, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		o.ID, orgID, o.ScheduleID, o.Name, o.ChatID, o.StartsAt, o.EndsAt,
+	)
+	if err != nil {
+		return "", err
+	}
+	return o.ID, nil
+}
+
+func (p *PostgresStorage) GetOnCallOverrides(ctx context.Context, orgID, scheduleID string) ([]OnCallOverride, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT id, schedule_id, name, chat_id, starts_at, ends_at FROM on_call_overrides
+		WHERE schedule_id=$1 AND `+orgCond(2)+` ORDER BY starts_at`, scheduleID, orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []OnCallOverride
+	for rows.Next() {
+		var o OnCallOverride
+		if err := rows.Scan(&o.ID, &o.ScheduleID, &o.Name, &o.ChatID, &o.StartsAt, &o.EndsAt); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+func (p *PostgresStorage) DeleteOnCallOverride(ctx context.Context, orgID, scheduleID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`DELETE FROM on_call_overrides WHERE schedule_id=$1 AND id=$2 AND `+orgCond(3),
+		scheduleID, id, orgArg(orgID),
+	))
+}
+
+func scanSchedule(row rowScanner) (*OnCallSchedule, error) {
+	var s OnCallSchedule
+	var participants []byte
+	err := row.Scan(&s.ID, &s.OrgID, &s.Name, &s.Team, &s.Timezone, &s.RotationStart, &participants, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(participants, &s.Participants); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func nonNilParticipants(p []OnCallParticipant) []OnCallParticipant {
+	if p == nil {
+		return []OnCallParticipant{}
+	}
+	return p
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultOrgID owns the rows created before organizations existed and
+	// anything a platform-wide caller creates without picking a tenant.
+	DefaultOrgID = "00000000-0000-0000-0000-000000000001"
+
+	// AllOrgs lifts the tenant filter. Only platform-wide callers resolve to
+	// it; inserts always need a concrete organization.
+	AllOrgs = "*"
+)
+
+var ErrOrgRequired = errors.New("storage: a concrete organization is required")
+
+const orgColumns = `id, name, created_at`
+
+func (p *PostgresStorage) AddOrganization(ctx context.Context, o Organization) (string, error) {
+	if o.ID == "" {
+		o.ID = uuid.New().String()
+	}
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO organizations (id, name) VALUES ($1, $2)`, o.ID, o.Name,
+	)
+	if err != nil {
+		return "", duplicate(err)
+	}
+	return o.ID, nil
+}
+
+func (p *PostgresStorage) GetOrganizations(ctx context.Context) ([]Organization, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+orgColumns+` FROM organizations ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []Organization
+	for rows.Next() {
+		var o Organization
+		if err := rows.Scan(&o.ID, &o.Name, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
+func (p *PostgresStorage) GetOrganizationByID(ctx context.Context, id string) (*Organization, error) {
+	var o Organization
+	err := p.db.QueryRowContext(ctx,
+		`SELECT `+orgColumns+` FROM organizations WHERE id=$1`, id,
+	).Scan(&o.ID, &o.Name, &o.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// orgCond restricts a query to the tenant bound to parameter $n. The
+// parameter is NULL for AllOrgs, which matches every tenant.
+func orgCond(n int) string {
+	return fmt.Sprintf("($%d::uuid IS NULL OR org_id = $%d)", n, n)
+}
+
+func orgArg(orgID string) any {
+	if orgID == AllOrgs {
+		return nil
+	}
+	return orgID
+}
+
+func requireOrg(orgID string) error {
+	if orgID == "" || orgID == AllOrgs {
+		return ErrOrgRequired
+	}
+	return nil
+}
@@ -3,14 +3,17 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type PostgresStorage struct {
-	db *sql.DB
+	db tracedDB
 }
 
 func NewPostgresStorage(dsn string) (*PostgresStorage, error) {
@@ -22,66 +25,358 @@ func NewPostgresStorage(dsn string) (*PostgresStorage, error) {
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping postgres: %w", err)
 	}
-	return &PostgresStorage{db: db}, nil
+	return &PostgresStorage{db: tracedDB{db}}, nil
 }
 
-func (p *PostgresStorage) AddSite(ctx context.Context, site Site) (string, error) {
+func (p *PostgresStorage) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+const siteColumns = `id, org_id, external_id, url, active, tags, team, name, description, owner, runbook_url,
+	labels, version`
+
+// siteSet writes every editable column from siteValues and bumps the version.
+const siteSet = `url=$1, active=$2, tags=$3, team=$4, name=$5, description=$6, owner=$7, runbook_url=$8,
+	labels=$9, external_id=$10, version=version+1`
+
+// siteInsert takes siteValues followed by the ID and the organization.
+const siteInsert = `INSERT INTO sites (url, active, tags, team, name, description, owner, runbook_url, labels,
+	external_id, id, org_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+func (p *PostgresStorage) AddSite(ctx context.Context, orgID string, site Site) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
 	if site.ID == "" {
 		site.ID = uuid.New().String()
 	}
-	_, err := p.db.ExecContext(ctx,
-		`INSERT INTO sites (id, url, active) VALUES ($1, $2, $3)`,
-		site.ID, site.URL, site.Active,
-	)
+	values, err := siteValues(site)
 	if err != nil {
 		return "", err
 	}
+	_, err = p.db.ExecContext(ctx, siteInsert, append(values, site.ID, orgID)...)
+	if err != nil {
+		return "", duplicate(err)
+	}
 	return site.ID, nil
 }
 
-func (p *PostgresStorage) GetSites(ctx context.Context) ([]Site, error) {
-	rows, err := p.db.QueryContext(ctx, `SELECT id, url, active FROM sites`)
+// GetSites returns one page of sites in keyset order, so that a page stays
+// cheap however deep the cursor is.
+func (p *PostgresStorage) GetSites(ctx context.Context, orgID string, f SiteFilter) (*SitePage, error) {
+	where := ` WHERE ` + orgCond(1)
+	args := []any{orgArg(orgID)}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where += ` AND ` + fmt.Sprintf(cond, "$"+strconv.Itoa(len(args)))
+	}
+	if f.Active != nil {
+		add(`active = %s`, *f.Active)
+	}
+	if len(f.Tags) > 0 {
+		add(`tags @> %s`, pq.Array(f.Tags))
+	}
+	if len(f.Labels) > 0 {
+		labels, err := json.Marshal(f.Labels)
+		if err != nil {
+			return nil, err
+		}
+		add(`labels @> %s::jsonb`, labels)
+	}
+	if len(f.LabelKeys) > 0 {
+		add(`labels ?& %s`, pq.Array(f.LabelKeys))
+	}
+	if f.ExternalID != "" {
+		add(`external_id = %s`, f.ExternalID)
+	}
+	if f.CheckType != "" {
+		add(`split_part(url, '://', 1) = %s`, f.CheckType)
+	}
+	if f.URLContains != "" {
+		add(`strpos(lower(url), lower(%s)) > 0`, f.URLContains)
+	}
+
+	page := &SitePage{Sites: []Site{}}
+	if err := p.db.QueryRowContext(ctx, `SELECT count(*) FROM sites`+where, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	sortKey := f.Sort
+	if sortKey == "" {
+		sortKey = SiteSortURL
+	}
+	column, ok := siteSortColumns[sortKey]
+	if !ok {
+		return nil, fmt.Errorf("storage: unknown site sort %q", sortKey)
+	}
+	dir, cmp := "ASC", ">"
+	if f.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if f.Cursor != "" {
+		c, err := parseSiteCursor(f.Cursor, sortKey, f.Desc)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, c.Value, c.ID)
+		where += fmt.Sprintf(` AND (%s, id) %s ($%d, $%d::uuid)`, column, cmp, len(args)-1, len(args))
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultSiteLimit
+	}
+	limit = min(limit, maxSiteLimit)
+	args = append(args, limit+1)
+
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+siteColumns+` FROM sites`+where+
+			fmt.Sprintf(` ORDER BY %s %s, id %s LIMIT $%d`, column, dir, dir, len(args)),
+		args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var sites []Site
 	for rows.Next() {
-		var s Site
-		if err := rows.Scan(&s.ID, &s.URL, &s.Active); err != nil {
+		s, err := scanSite(rows)
+		if err != nil {
 			return nil, err
 		}
-		sites = append(sites, s)
+		page.Sites = append(page.Sites, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Sites) > limit {
+		page.Sites = page.Sites[:limit]
+		last := page.Sites[limit-1]
+		page.NextCursor = encodeSiteCursor(siteCursor{
+			Sort: sortKey, Desc: f.Desc, Value: siteSortValue(last, sortKey), ID: last.ID,
+		})
 	}
-	return sites, nil
+	return page, nil
 }
 
-func (p *PostgresStorage) GetSiteByID(ctx context.Context, id string) (*Site, error) {
-	var s Site
-	err := p.db.QueryRowContext(ctx,
-		`SELECT id, url, active FROM sites WHERE id=$1`, id,
-	).Scan(&s.ID, &s.URL, &s.Active)
+func (p *PostgresStorage) GetSiteByID(ctx context.Context, orgID, id string) (*Site, error) {
+	s, err := scanSite(p.db.QueryRowContext(ctx,
+		`SELECT `+siteColumns+` FROM sites WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	return s, nil
+}
+
+// UpdateSite replaces the site and bumps its version. A non-zero site.Version
+// is the version the caller last saw.
+func (p *PostgresStorage) UpdateSite(ctx context.Context, orgID string, site Site) (*Site, error) {
+	values, err := siteValues(site)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := scanSite(p.db.QueryRowContext(ctx,
+		`UPDATE sites SET `+siteSet+`
+		WHERE id=$11 AND `+orgCond(12)+` AND ($13::bigint = 0 OR version = $13)
+		RETURNING `+siteColumns,
+		append(values, site.ID, orgArg(orgID), site.Version)...,
+	))
+	if err == sql.ErrNoRows {
+		return nil, p.siteMissing(ctx, orgID, site.ID)
+	}
+	if err != nil {
+		return nil, duplicate(err)
+	}
+	return updated, nil
+}
+
+// PatchSite locks the site, lets apply change it and stores the result with
+// a bumped version. apply sees the current row; its errors are returned as is.
+func (p *PostgresStorage) PatchSite(ctx context.Context, orgID, id string, version int64, apply func(s *Site) error) (*Site, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	cur, err := scanSite(tx.QueryRowContext(ctx,
+		`SELECT `+siteColumns+` FROM sites WHERE id=$1 AND `+orgCond(2)+` FOR UPDATE`, id, orgArg(orgID),
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && cur.Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	site := *cur
+	if err := apply(&site); err != nil {
+		return nil, err
+	}
+
+	values, err := siteValues(site)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := scanSite(tx.QueryRowContext(ctx,
+		`UPDATE sites SET `+siteSet+` WHERE id=$11 RETURNING `+siteColumns,
+		append(values, cur.ID)...,
+	))
+	if err != nil {
+		return nil, duplicate(err)
+	}
+	return updated, tx.Commit()
+}
+
+// siteMissing tells why a conditional site update matched no row.
+func (p *PostgresStorage) siteMissing(ctx context.Context, orgID, id string) error {
+	s, err := p.GetSiteByID(ctx, orgID, id)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+func (p *PostgresStorage) DeleteSite(ctx context.Context, orgID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`DELETE FROM sites WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+}
+
+// siteValues are the editable columns in the order of siteSet.
+func siteValues(s Site) ([]any, error) {
+	labels, err := json.Marshal(nonNilLabels(s.Labels))
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		s.URL, s.Active, pq.Array(nonNilStrings(s.Tags)), s.Team,
+		s.Name, s.Description, s.Owner, s.RunbookURL, labels, s.ExternalID,
+	}, nil
+}
+
+func scanSite(row rowScanner) (*Site, error) {
+	var s Site
+	var labels []byte
+	if err := row.Scan(&s.ID, &s.OrgID, &s.ExternalID, &s.URL, &s.Active, pq.Array(&s.Tags), &s.Team,
+		&s.Name, &s.Description, &s.Owner, &s.RunbookURL, &labels, &s.Version); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(labels, &s.Labels); err != nil {
+		return nil, err
+	}
 	return &s, nil
 }
 
-func (p *PostgresStorage) UpdateSite(ctx context.Context, site Site) error {
-	_, err := p.db.ExecContext(ctx,
-		`UPDATE sites SET url=$1, active=$2 WHERE id=$3`,
-		site.URL, site.Active, site.ID,
-	)
-	return err
+func nonNilLabels(l map[string]string) map[string]string {
+	if l == nil {
+		return map[string]string{}
+	}
+	return l
 }
 
-func (p *PostgresStorage) DeleteSite(ctx context.Context, id string) error {
-	_, err := p.db.ExecContext(ctx,
-		`DELETE FROM sites WHERE id=$1`, id,
-	)
-	return err
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// ImportSites writes sites in one transaction, matching existing ones by
+// external ID when the site has one and by URL otherwise. Sites that exist
+// fail unless opts.Update is set. The transaction is
+// committed only if every site went through and opts.DryRun is unset, so an
+// import applies fully or not at all; the results are the same either way.
+func (p *PostgresStorage) ImportSites(ctx context.Context, orgID string, sites []Site, opts ImportOptions) ([]SiteImport, error) {
+	if err := requireOrg(orgID); err != nil {
+		return nil, err
+	}
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]SiteImport, len(sites))
+	failed := false
+	for i, site := range sites {
+		res := &results[i]
+		res.Site = site
+		res.Site.OrgID = orgID
+
+		before, err := scanSite(tx.QueryRowContext(ctx,
+			`SELECT `+siteColumns+` FROM sites
+			WHERE org_id=$1 AND (($3 <> '' AND external_id=$3) OR ($3 = '' AND url=$2))
+			FOR UPDATE`, orgID, site.URL, site.ExternalID,
+		))
+		switch {
+		case err == sql.ErrNoRows:
+			res.Action = ImportCreate
+		case err != nil:
+			return nil, err
+		case !opts.Update:
+			res.Err = ErrDuplicate
+			failed = true
+			continue
+		default:
+			res.Action = ImportUpdate
+			res.Before = before
+		}
+
+		values, err := siteValues(site)
+		if err != nil {
+			return nil, err
+		}
+		// A row can still collide with another site, e.g. an update moving
+		// to a URL that is taken. The savepoint keeps the transaction usable
+		// so the error lands on that row and the rest are still checked.
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT import_site`); err != nil {
+			return nil, err
+		}
+		var row *sql.Row
+		if res.Action == ImportCreate {
+			row = tx.QueryRowContext(ctx,
+				siteInsert+` RETURNING `+siteColumns,
+				append(values, uuid.New().String(), orgID)...,
+			)
+		} else {
+			row = tx.QueryRowContext(ctx,
+				`UPDATE sites SET `+siteSet+` WHERE id=$11 RETURNING `+siteColumns,
+				append(values, before.ID)...,
+			)
+		}
+		written, err := scanSite(row)
+		if err = duplicate(err); errors.Is(err, ErrDuplicate) {
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_site`); err != nil {
+				return nil, err
+			}
+			res.Err = ErrDuplicate
+			failed = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT import_site`); err != nil {
+			return nil, err
+		}
+		res.Site = *written
+	}
+
+	if failed || opts.DryRun {
+		return results, nil
+	}
+	return results, tx.Commit()
 }
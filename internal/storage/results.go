@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const checkResultColumns = `site_id, org_id, url, status, up, response_time_ms, error, maintenance, checked_at`
+
+const (
+	defaultResultsLimit = 20
+	maxResultsLimit     = 1000
+)
+
+func (p *PostgresStorage) AddCheckResult(ctx context.Context, orgID string, r CheckResult) error {
+	if err := requireOrg(orgID); err != nil {
+		return err
+	}
+	if r.CheckedAt.IsZero() {
+		r.CheckedAt = time.Now()
+	}
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO check_results (`+checkResultColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		nullString(r.SiteID), orgID, r.URL, r.Status, r.Up, r.ResponseTimeMs, r.Error, r.Maintenance, r.CheckedAt,
+	)
+	return err
+}
+
+// GetCheckResults returns the newest results of a site first.
+func (p *PostgresStorage) GetCheckResults(ctx context.Context, orgID, siteID string, limit int) ([]CheckResult, error) {
+	if limit <= 0 {
+		limit = defaultResultsLimit
+	}
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+checkResultColumns+` FROM check_results WHERE site_id=$1 AND `+orgCond(2)+`
+		ORDER BY checked_at DESC LIMIT $3`,
+		siteID, orgArg(orgID), min(limit, maxResultsLimit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []CheckResult
+	for rows.Next() {
+		var r CheckResult
+		var site sql.NullString
+		err := rows.Scan(&site, &r.OrgID, &r.URL, &r.Status, &r.Up, &r.ResponseTimeMs, &r.Error, &r.Maintenance, &r.CheckedAt)
+		if err != nil {
+			return nil, err
+		}
+		r.SiteID = site.String
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// DeleteCheckResultsBefore prunes results of all organizations checked
+// before the given time and returns how many were removed.
+func (p *PostgresStorage) DeleteCheckResultsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM check_results WHERE checked_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
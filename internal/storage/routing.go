@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	channelColumns = `id, org_id, name, type, settings, created_at`
+	routeColumns   = `id, org_id, name, priority, tags, teams, severities, weekdays, start_time, end_time, timezone,
+	channel_ids, continue_matching, created_at`
+)
+
+func (p *PostgresStorage) AddChannel(ctx context.Context, orgID string, c NotificationChannel) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	settings, err := json.Marshal(nonNilSettings(c.Settings))
+	if err != nil {
+		return "", err
+	}
+	_, err = p.db.ExecContext(ctx,
+		`INSERT INTO notification_channels (id, org_id, name, type, settings) VALUES ($1, $2, $3, $4, $5)`,
+		c.ID, orgID, c.Name, c.Type, settings,
+	)
+	if err != nil {
+		return "", err
+	}
+	return c.ID, nil
+}
+
+func (p *PostgresStorage) GetChannels(ctx context.Context, orgID string) ([]NotificationChannel, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+channelColumns+` FROM notification_channels WHERE `+orgCond(1)+` ORDER BY created_at`,
+		orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []NotificationChannel
+	for rows.Next() {
+		c, err := scanChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, *c)
+	}
+	return channels, rows.Err()
+}
+
+func (p *PostgresStorage) GetChannelByID(ctx context.Context, orgID, id string) (*NotificationChannel, error) {
+	c, err := scanChannel(p.db.QueryRowContext(ctx,
+		`SELECT `+channelColumns+` FROM notification_channels WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (p *PostgresStorage) UpdateChannel(ctx context.Context, orgID string, c NotificationChannel) error {
+	settings, err := json.Marshal(nonNilSettings(c.Settings))
+	if err != nil {
+		return err
+	}
+	return affected(p.db.ExecContext(ctx,
+		`UPDATE notification_channels SET name=$1, type=$2, settings=$3 WHERE id=$4 AND `+orgCond(5),
+		c.Name, c.Type, settings, c.ID, orgArg(orgID),
+	))
+}
+
+func (p *PostgresStorage) DeleteChannel(ctx context.Context, orgID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`DELETE FROM notification_channels WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+}
+
+func (p *PostgresStorage) AddRoute(ctx context.Context, orgID string, r Route) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO routes (id, org_id, name, priority, tags, teams, severities, weekdays, start_time, end_time,
+			timezone, channel_ids, continue_matching)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		r.ID, orgID, r.Name, r.Priority, pq.Array(nonNilStrings(r.Tags)), pq.Array(nonNilStrings(r.Teams)),
+		pq.Array(nonNilStrings(r.Severities)), pq.Array(toInt64s(r.Weekdays)), r.StartTime, r.EndTime,
+		r.Timezone, pq.Array(nonNilStrings(r.ChannelIDs)), r.Continue,
+	)
+	if err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+func (p *PostgresStorage) GetRoutes(ctx context.Context, orgID string) ([]Route, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+routeColumns+` FROM routes WHERE `+orgCond(1)+` ORDER BY priority, created_at`,
+		orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routes []Route
+	for rows.Next() {
+		r, err := scanRoute(rows)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, *r)
+	}
+	return routes, rows.Err()
+}
+
+func (p *PostgresStorage) GetRouteByID(ctx context.Context, orgID, id string) (*Route, error) {
+	r, err := scanRoute(p.db.QueryRowContext(ctx,
+		`SELECT `+routeColumns+` FROM routes WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (p *PostgresStorage) UpdateRoute(ctx context.Context, orgID string, r Route) error {
+	return affected(p.db.ExecContext(ctx,
+		`UPDATE routes SET name=$1, priority=$2, tags=$3, teams=$4, severities=$5, weekdays=$6, start_time=$7,
+			end_time=$8, timezone=$9, channel_ids=$10, continue_matching=$11
+		WHERE id=$12 AND `+orgCond(13),
+		r.Name, r.Priority, pq.Array(nonNilStrings(r.Tags)), pq.Array(nonNilStrings(r.Teams)),
+		pq.Array(nonNilStrings(r.Severities)), pq.Array(toInt64s(r.Weekdays)), r.StartTime, r.EndTime,
+		r.Timezone, pq.Array(nonNilStrings(r.ChannelIDs)), r.Continue, r.ID, orgArg(orgID),
+	))
+}
+
+func (p *PostgresStorage) DeleteRoute(ctx context.Context, orgID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`DELETE FROM routes WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+}
+
+func scanChannel(row rowScanner) (*NotificationChannel, error) {
+	var c NotificationChannel
+	var settings []byte
+	if err := row.Scan(&c.ID, &c.OrgID, &c.Name, &c.Type, &settings, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(settings, &c.Settings); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanRoute(row rowScanner) (*Route, error) {
+	var r Route
+	var weekdays pq.Int64Array
+	err := row.Scan(&r.ID, &r.OrgID, &r.Name, &r.Priority, pq.Array(&r.Tags), pq.Array(&r.Teams), pq.Array(&r.Severities),
+		&weekdays, &r.StartTime, &r.EndTime, &r.Timezone, pq.Array(&r.ChannelIDs), &r.Continue, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range weekdays {
+		r.Weekdays = append(r.Weekdays, int(d))
+	}
+	return &r, nil
+}
+
+func nonNilSettings(s map[string]string) map[string]string {
+	if s == nil {
+		return map[string]string{}
+	}
+	return s
+}
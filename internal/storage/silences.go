@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const silenceColumns = `id, org_id, site_id, tag, url_pattern, comment, created_by, created_at, expires_at`
+
+func (p *PostgresStorage) AddSilence(ctx context.Context, orgID string, s Silence) (string, error) {
+	if err := requireOrg(orgID); err != nil {
+		return "", err
+	}
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	_, err := p.db.ExecContext(ctx,
+		`INSERT INTO silences (id, org_id, site_id, tag, url_pattern, comment, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		s.ID, orgID, nullString(s.SiteID), s.Tag, s.URLPattern, s.Comment, s.CreatedBy, s.ExpiresAt,
+	)
+	if err != nil {
+		return "", err
+	}
+	return s.ID, nil
+}
+
+func (p *PostgresStorage) GetSilences(ctx context.Context, orgID string, activeOnly bool) ([]Silence, error) {
+	query := `SELECT ` + silenceColumns + ` FROM silences WHERE ` + orgCond(1)
+	if activeOnly {
+		query += ` AND expires_at > now()`
+	}
+	query += ` ORDER BY created_at`
+
+	rows, err := p.db.QueryContext(ctx, query, orgArg(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var silences []Silence
+	for rows.Next() {
+		s, err := scanSilence(rows)
+		if err != nil {
+			return nil, err
+		}
+		silences = append(silences, *s)
+	}
+	return silences, rows.Err()
+}
+
+func (p *PostgresStorage) GetSilenceByID(ctx context.Context, orgID, id string) (*Silence, error) {
+	s, err := scanSilence(p.db.QueryRowContext(ctx,
+		`SELECT `+silenceColumns+` FROM silences WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *PostgresStorage) UpdateSilence(ctx context.Context, orgID string, s Silence) error {
+	return affected(p.db.ExecContext(ctx,
+		`UPDATE silences SET site_id=$1, tag=$2, url_pattern=$3, comment=$4, expires_at=$5
+		WHERE id=$6 AND `+orgCond(7),
+		nullString(s.SiteID), s.Tag, s.URLPattern, s.Comment, s.ExpiresAt, s.ID, orgArg(orgID),
+	))
+}
+
+func (p *PostgresStorage) DeleteSilence(ctx context.Context, orgID, id string) error {
+	return affected(p.db.ExecContext(ctx,
+		`DELETE FROM silences WHERE id=$1 AND `+orgCond(2), id, orgArg(orgID),
+	))
+}
+
+func scanSilence(row rowScanner) (*Silence, error) {
+	var s Silence
+	var siteID sql.NullString
+	err := row.Scan(&s.ID, &s.OrgID, &siteID, &s.Tag, &s.URLPattern, &s.Comment, &s.CreatedBy, &s.CreatedAt, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	s.SiteID = siteID.String
+	return &s, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"site-monitor/pkg/tracing"
+)
+
+var tracer = otel.Tracer("site-monitor/internal/storage")
+
+// tracedDB wraps *sql.DB so every query gets a client span. Rows are read
+// after the span ends, so query spans cover execution but not scanning.
+type tracedDB struct {
+	*sql.DB
+}
+
+func (d tracedDB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	op := "QUERY"
+	if f := strings.Fields(query); len(f) > 0 {
+		op = strings.ToUpper(f[0])
+	}
+	return tracer.Start(ctx, "postgres "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(op),
+			semconv.DBQueryText(query),
+		))
+}
+
+func (d tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	res, err := d.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		tracing.RecordError(span, err)
+	}
+	return res, err
+}
+
+func (d tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		tracing.RecordError(span, err)
+	}
+	return rows, err
+}
+
+func (d tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	row := d.DB.QueryRowContext(ctx, query, args...)
+	if err := row.Err(); err != nil {
+		tracing.RecordError(span, err)
+	}
+	return row
+}
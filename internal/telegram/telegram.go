@@ -1,6 +1,7 @@
 package telegram
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -15,11 +16,39 @@ func NewClient(token, chatID string) *Client {
 	return &Client{token: token, chatID: chatID}
 }
 
+func (c *Client) Name() string {
+	return "telegram"
+}
+
+// Ping checks that the Bot API is reachable and accepts the token.
+func (c *Client) Ping(ctx context.Context) error {
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/getMe", c.token)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram API error: %s", resp.Status)
+	}
+	return nil
+}
+
 func (c *Client) SendMessage(message string) error {
+	return c.SendMessageTo(c.chatID, message)
+}
+
+func (c *Client) SendMessageTo(chatID, message string) error {
 	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", c.token)
 
 	resp, err := http.PostForm(endpoint, url.Values{
-		"chat_id":    {c.chatID},
+		"chat_id":    {chatID},
 		"text":       {message},
 		"parse_mode": {"Markdown"},
 	})
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type Client struct {
+	url string
+}
+
+func NewClient(url string) *Client {
+	return &Client{url: url}
+}
+
+func (c *Client) Name() string {
+	return "webhook"
+}
+
+func (c *Client) SendMessage(message string) error {
+	body, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook error: %s", resp.Status)
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ CREATE TABLE IF NOT EXISTS sites (
     active BOOLEAN NOT NULL DEFAULT true
 );
 
-INSERT INTO sites (id, url, active) VALUES
-
+-- Re-run by the migration runner on databases created before it existed; the
+-- demo sites are only seeded into the pre-tenancy schema this file creates.
+INSERT INTO sites (id, url, active)
+SELECT v.id::uuid, v.url, v.active FROM (VALUES
 ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 'https://yandex.ru', true),
 ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a12', 'https://mail.ru', true),
 ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a13', 'https://vk.com', true),
@@ -15,13 +17,8 @@ INSERT INTO sites (id, url, active) VALUES
 ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a17', 'https://github.com', true),
 ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a19', 'https://stackoveqweqweqweeerflow.com', true),
 ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a21', 'https://stackoeqewqeqeverflow.com', true)
-ON CONFLICT (url) DO NOTHING;
-
-
-
-
-
-
-
-
-
+) AS v (id, url, active)
+WHERE NOT EXISTS (SELECT 1 FROM sites s WHERE s.url = v.url)
+    AND NOT EXISTS (
+        SELECT 1 FROM information_schema.columns WHERE table_name = 'sites' AND column_name = 'org_id'
+    );
@@ -0,0 +1,18 @@
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    site_ids UUID[] NOT NULL DEFAULT '{}',
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    suppress TEXT NOT NULL DEFAULT 'alerts',
+    schedule_type TEXT NOT NULL DEFAULT 'once',
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    cron TEXT NOT NULL DEFAULT '',
+    weekdays INT[] NOT NULL DEFAULT '{}',
+    start_time TEXT NOT NULL DEFAULT '',
+    duration_minutes INT NOT NULL DEFAULT 0,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
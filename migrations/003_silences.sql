@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS silences (
+    id UUID PRIMARY KEY,
+    site_id UUID,
+    tag TEXT NOT NULL DEFAULT '',
+    url_pattern TEXT NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS silences_expires_at_idx ON silences (expires_at);
+
+CREATE TABLE IF NOT EXISTS incidents (
+    id UUID PRIMARY KEY,
+    site_id UUID,
+    url TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    opened_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMPTZ,
+    acknowledged_at TIMESTAMPTZ,
+    acknowledged_by TEXT NOT NULL DEFAULT '',
+    ack_comment TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS incidents_open_url_idx ON incidents (url) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS suppressed_events (
+    id UUID PRIMARY KEY,
+    site_id UUID,
+    url TEXT NOT NULL,
+    status INT NOT NULL,
+    reason TEXT NOT NULL,
+    silence_id UUID,
+    maintenance_window_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS suppressed_events_created_at_idx ON suppressed_events (created_at);
@@ -0,0 +1,34 @@
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS team TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS on_call_schedules (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    team TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    rotation_start TIMESTAMPTZ NOT NULL,
+    participants JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS on_call_overrides (
+    id UUID PRIMARY KEY,
+    schedule_id UUID NOT NULL REFERENCES on_call_schedules (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    chat_id TEXT NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    team TEXT NOT NULL UNIQUE,
+    levels JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS escalation_policy_id UUID REFERENCES escalation_policies (id) ON DELETE SET NULL;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS escalation_level INT NOT NULL DEFAULT 0;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS next_escalation_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS incidents_next_escalation_idx ON incidents (next_escalation_at) WHERE status = 'open';
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    settings JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS routes (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    teams TEXT[] NOT NULL DEFAULT '{}',
+    severities TEXT[] NOT NULL DEFAULT '{}',
+    weekdays INT[] NOT NULL DEFAULT '{}',
+    start_time TEXT NOT NULL DEFAULT '',
+    end_time TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    channel_ids UUID[] NOT NULL DEFAULT '{}',
+    continue_matching BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO organizations (id, name) VALUES ('00000000-0000-0000-0000-000000000001', 'default')
+ON CONFLICT (id) DO NOTHING;
+
+-- Existing rows move to the default organization. The default is dropped
+-- right after so that every new row has to name its tenant explicitly.
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE maintenance_windows ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE silences ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE suppressed_events ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE on_call_schedules ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE on_call_overrides ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE escalation_policies ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE notification_channels ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS org_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+
+ALTER TABLE sites ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE maintenance_windows ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE silences ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE incidents ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE suppressed_events ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE on_call_schedules ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE on_call_overrides ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE escalation_policies ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE notification_channels ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE routes ALTER COLUMN org_id DROP DEFAULT;
+
+-- API keys without an organization are platform-wide, e.g. the checker's
+-- service key that has to see the sites of every tenant.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations (id);
+
+-- Uniqueness that used to be global is now per tenant.
+ALTER TABLE sites DROP CONSTRAINT IF EXISTS sites_url_key;
+CREATE UNIQUE INDEX IF NOT EXISTS sites_org_url_idx ON sites (org_id, url);
+
+ALTER TABLE escalation_policies DROP CONSTRAINT IF EXISTS escalation_policies_team_key;
+CREATE UNIQUE INDEX IF NOT EXISTS escalation_policies_org_team_idx ON escalation_policies (org_id, team);
+
+DROP INDEX IF EXISTS incidents_open_url_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS incidents_open_org_url_idx ON incidents (org_id, url) WHERE status = 'open';
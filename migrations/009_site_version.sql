@@ -0,0 +1,3 @@
+-- Bumped on every update so that clients can detect concurrent edits through
+-- ETag / If-Match.
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- Keyset pagination of GET /sites orders by (url, id) or (team, id); the tag
+-- filter uses array containment.
+CREATE INDEX IF NOT EXISTS sites_url_id_idx ON sites (url, id);
+CREATE INDEX IF NOT EXISTS sites_team_id_idx ON sites (team, id);
+CREATE INDEX IF NOT EXISTS sites_tags_idx ON sites USING GIN (tags);
@@ -0,0 +1,8 @@
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '';
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS runbook_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+
+-- GET /sites?label=env=prod filters with containment and existence operators.
+CREATE INDEX IF NOT EXISTS sites_labels_idx ON sites USING GIN (labels);
@@ -0,0 +1,3 @@
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS external_id TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS sites_org_external_id_idx ON sites (org_id, external_id) WHERE external_id <> '';
@@ -0,0 +1,18 @@
+-- Every check result the alert service receives, for GET /sites/{id}/status
+-- and /results. Rows older than results.retention_hours are pruned by the
+-- alert service.
+CREATE TABLE IF NOT EXISTS check_results (
+    id BIGSERIAL PRIMARY KEY,
+    org_id UUID NOT NULL REFERENCES organizations (id),
+    site_id UUID,
+    url TEXT NOT NULL,
+    status INT NOT NULL,
+    up BOOLEAN NOT NULL,
+    response_time_ms INT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    maintenance BOOLEAN NOT NULL DEFAULT false,
+    checked_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS check_results_site_checked_at_idx ON check_results (site_id, checked_at DESC);
+CREATE INDEX IF NOT EXISTS check_results_checked_at_idx ON check_results (checked_at);
@@ -0,0 +1,5 @@
+-- The checker marks the windows it saw active and, once a window has ended,
+-- when it sent the summary for it, so a restart in between doesn't lose the
+-- summary.
+ALTER TABLE maintenance_windows ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ;
+ALTER TABLE maintenance_windows ADD COLUMN IF NOT EXISTS summary_sent_at TIMESTAMPTZ;
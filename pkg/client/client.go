@@ -0,0 +1,238 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"site-monitor/pkg/utils"
+)
+
+const pageSize = 500
+
+type Config struct {
+	URL string
+	// APIKey is sent as X-API-Key; Token, if set instead, as a bearer token.
+	APIKey string
+	Token  string
+	// OrgID picks the organization for platform-wide credentials.
+	OrgID   string
+	Timeout time.Duration
+}
+
+// Client is a small typed wrapper over the CRUD API.
+type Client struct {
+	cfg  Config
+	http *http.Client
+}
+
+func New(cfg Config) *Client {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	return &Client{cfg: cfg, http: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Error is a non-2xx answer of the API, carrying its problem+json body.
+type Error struct {
+	utils.Problem
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", e.Status, e.Title, e.Detail)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Title)
+}
+
+// StatusOf returns the HTTP status of an API error, or 0 for other errors.
+func StatusOf(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Status
+	}
+	return 0
+}
+
+// ListSites pages through GET /sites with the given filters and returns
+// every matching site.
+func (c *Client) ListSites(ctx context.Context, filter url.Values) ([]Site, error) {
+	q := url.Values{}
+	for k, v := range filter {
+		q[k] = v
+	}
+	q.Set("limit", strconv.Itoa(pageSize))
+	q.Del("cursor")
+
+	var all []Site
+	for {
+		var page []Site
+		resp, err := c.do(ctx, http.MethodGet, "/sites?"+q.Encode(), nil, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		next := resp.Header.Get("X-Next-Cursor")
+		if next == "" {
+			return all, nil
+		}
+		q.Set("cursor", next)
+	}
+}
+
+func (c *Client) GetSite(ctx context.Context, id string) (*Site, error) {
+	var s Site
+	if _, err := c.do(ctx, http.MethodGet, "/sites/"+url.PathEscape(id), nil, nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (c *Client) CreateSite(ctx context.Context, s Site) (*Site, error) {
+	var created Site
+	if _, err := c.do(ctx, http.MethodPost, "/sites", nil, s, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateSite replaces the site. A non-zero version is sent as If-Match, so
+// the update fails with 412 if someone changed the site in between.
+func (c *Client) UpdateSite(ctx context.Context, s Site, version int64) (*Site, error) {
+	var updated Site
+	if _, err := c.do(ctx, http.MethodPut, "/sites/"+url.PathEscape(s.ID), ifMatch(version), s, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// PatchSite applies a JSON merge patch, guarded by version like UpdateSite.
+func (c *Client) PatchSite(ctx context.Context, id string, patch map[string]any, version int64) (*Site, error) {
+	header := ifMatch(version)
+	header.Set("Content-Type", utils.MergePatchContentType)
+	var updated Site
+	if _, err := c.do(ctx, http.MethodPatch, "/sites/"+url.PathEscape(id), header, patch, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) DeleteSite(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/sites/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+// SetSiteActive pauses or resumes checks of a site.
+func (c *Client) SetSiteActive(ctx context.Context, id string, active bool) (*Site, error) {
+	return c.PatchSite(ctx, id, map[string]any{"active": active}, 0)
+}
+
+func (c *Client) WhoAmI(ctx context.Context) (*Principal, error) {
+	var p Principal
+	if _, err := c.do(ctx, http.MethodGet, "/whoami", nil, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (c *Client) GetSiteStatus(ctx context.Context, id string) (*SiteStatus, error) {
+	var st SiteStatus
+	if _, err := c.do(ctx, http.MethodGet, "/sites/"+url.PathEscape(id)+"/status", nil, nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// GetSiteResults returns up to limit latest checks of a site, newest first.
+// A zero limit leaves the choice to the server.
+func (c *Client) GetSiteResults(ctx context.Context, id string, limit int) ([]CheckResult, error) {
+	path := "/sites/" + url.PathEscape(id) + "/results"
+	if limit > 0 {
+		path += "?limit=" + strconv.Itoa(limit)
+	}
+	var results []CheckResult
+	if _, err := c.do(ctx, http.MethodGet, path, nil, nil, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CheckSite has the checker check the site now and returns the result.
+func (c *Client) CheckSite(ctx context.Context, id string) (*CheckResult, error) {
+	var res CheckResult
+	if _, err := c.do(ctx, http.MethodPost, "/sites/"+url.PathEscape(id)+"/check", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func ifMatch(version int64) http.Header {
+	h := http.Header{}
+	if version != 0 {
+		h.Set("If-Match", `"`+strconv.FormatInt(version, 10)+`"`)
+	}
+	return h
+}
+
+// do sends body as JSON and decodes a 2xx answer into out, if out is set.
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, body, out any) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.URL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.cfg.APIKey != "":
+		req.Header.Set("X-API-Key", c.cfg.APIKey)
+	case c.cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+	if c.cfg.OrgID != "" {
+		req.Header.Set("X-Org-ID", c.cfg.OrgID)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr.Problem); err != nil || apiErr.Status == 0 {
+			apiErr.Status = resp.StatusCode
+			apiErr.Title = http.StatusText(resp.StatusCode)
+		}
+		return resp, apiErr
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("decode %s %s: %w", method, path, err)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import "time"
+
+// Site mirrors the API's site object. It has the same fields as the server's
+// own type, so code on both sides can convert between them.
+type Site struct {
+	ID     string   `json:"id"`
+	OrgID  string   `json:"org_id"`
+	URL    string   `json:"url"`
+	Active bool     `json:"active"`
+	Tags   []string `json:"tags"`
+	Team   string   `json:"team"`
+
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Owner       string            `json:"owner"`
+	RunbookURL  string            `json:"runbook_url"`
+	Labels      map[string]string `json:"labels"`
+	ExternalID  string            `json:"external_id"`
+
+	Version int64 `json:"version"`
+}
+
+// CheckResult is one check of a site. Up means the site answered 200.
+type CheckResult struct {
+	SiteID         string    `json:"site_id,omitempty"`
+	OrgID          string    `json:"org_id"`
+	URL            string    `json:"url"`
+	Status         int       `json:"status"`
+	Up             bool      `json:"up"`
+	ResponseTimeMs int       `json:"response_time_ms"`
+	Error          string    `json:"error,omitempty"`
+	Maintenance    bool      `json:"maintenance"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+type Incident struct {
+	ID             string     `json:"id"`
+	OrgID          string     `json:"org_id"`
+	SiteID         string     `json:"site_id,omitempty"`
+	URL            string     `json:"url"`
+	Status         string     `json:"status"`
+	OpenedAt       time.Time  `json:"opened_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	AckComment     string     `json:"ack_comment,omitempty"`
+
+	EscalationPolicyID string     `json:"escalation_policy_id,omitempty"`
+	EscalationLevel    int        `json:"escalation_level"`
+	NextEscalationAt   *time.Time `json:"next_escalation_at,omitempty"`
+}
+
+// SiteStatus is the state of a site after its last check: up, down, paused
+// or unknown when it hasn't been checked yet.
+type SiteStatus struct {
+	SiteID    string       `json:"site_id"`
+	URL       string       `json:"url"`
+	State     string       `json:"state"`
+	LastCheck *CheckResult `json:"last_check"`
+	Incident  *Incident    `json:"incident,omitempty"`
+}
+
+// Principal is the caller as the API sees it. Global principals act on
+// every organization unless Config.OrgID picks one.
+type Principal struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+	OrgID   string `json:"org_id"`
+	Global  bool   `json:"global"`
+}
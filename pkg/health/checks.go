@@ -0,0 +1,48 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka succeeds if at least one of the brokers accepts a connection.
+func Kafka(brokers []string) Check {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, broker := range brokers {
+			conn, err := kafka.DialContext(ctx, "tcp", broker)
+			if err == nil {
+				return conn.Close()
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return errors.New("no brokers configured")
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// HTTP succeeds if url answers with a 2xx status.
+func HTTP(url string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
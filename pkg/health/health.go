@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"site-monitor/pkg/logger"
+	"site-monitor/pkg/utils"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusError       = "error"
+
+	DefaultTimeout = 2 * time.Second
+)
+
+// Check reports whether a single dependency is reachable. It must respect
+// ctx; Readiness also enforces the timeout for checks that can't.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Liveness answers ok as long as the process can serve HTTP. It never looks
+// at dependencies, so an outage doesn't get the service restarted.
+func Liveness(log *logger.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		utils.WriteJSON(log, w, Report{Status: StatusOK}, http.StatusOK)
+	})
+}
+
+// Readiness runs all checks in parallel, each bounded by timeout, and answers
+// 503 with the per-dependency breakdown if any of them fails.
+func Readiness(log *logger.Logger, timeout time.Duration, checks map[string]Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := Run(r.Context(), timeout, checks)
+
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+			log.Ctx(r.Context()).Warnw("Readiness check failed", "checks", report.Checks)
+		}
+		utils.WriteJSON(log, w, report, status)
+	})
+}
+
+func Run(ctx context.Context, timeout time.Duration, checks map[string]Check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			res := runCheck(ctx, timeout, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if res.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func runCheck(ctx context.Context, timeout time.Duration, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- fmt.Errorf("check panicked: %v", rec)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	res := CheckResult{Status: StatusOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Status = StatusError
+		res.Error = err.Error()
+	}
+	return res
+}
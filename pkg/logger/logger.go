@@ -1,16 +1,38 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+var Version = "dev"
+
 type Logger struct {
-	Sugar *zap.SugaredLogger
+	Sugar   *zap.SugaredLogger
+	sampled *zap.SugaredLogger
+	level   zap.AtomicLevel
+}
+
+type Config struct {
+	Format   string `yaml:"format" default:"console"`
+	Level    string `yaml:"level" default:"info"`
+	Service  string `yaml:"service"`
+	Version  string `yaml:"version"`
+	Sampling struct {
+		Initial    int `yaml:"initial" default:"100"`
+		Thereafter int `yaml:"thereafter" default:"100"`
+	} `yaml:"sampling"`
 }
 
 func SetupLogger() (*Logger, error) {
 	config := zap.NewDevelopmentConfig()
+	config.Encoding = "redacted-console"
 	config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	config.DisableStacktrace = true
 
@@ -20,9 +42,113 @@ func SetupLogger() (*Logger, error) {
 		return nil, err
 	}
 
-	return &Logger{logger.Sugar()}, nil
+	return &Logger{Sugar: logger.Sugar(), sampled: logger.Sugar(), level: config.Level}, nil
+}
+
+func New(cfg Config, service string) (*Logger, error) {
+	level, err := zap.ParseAtomicLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var config zap.Config
+	switch cfg.Format {
+	case "json":
+		config = zap.NewProductionConfig()
+		config.Encoding = "redacted-json"
+		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	case "console":
+		config = zap.NewDevelopmentConfig()
+		config.Development = false
+		config.Encoding = "redacted-console"
+		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	config.Level = level
+	config.DisableStacktrace = true
+	config.Sampling = nil
+
+	if cfg.Service != "" {
+		service = cfg.Service
+	}
+	version := cfg.Version
+	if version == "" {
+		version = Version
+	}
+	config.InitialFields = map[string]interface{}{
+		"service": service,
+		"version": version,
+	}
+
+	logger, err := config.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	sampled := logger
+	if cfg.Sampling.Initial > 0 {
+		sampled = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewSamplerWithOptions(core, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+		}))
+	}
+
+	return &Logger{Sugar: logger.Sugar(), sampled: sampled.Sugar(), level: level}, nil
+}
+
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(lvl)
+	return nil
+}
+
+func (l *Logger) LevelHandler() http.Handler {
+	return l.level
 }
 
 func (l *Logger) Sync() {
 	l.Sugar.Sync()
 }
+
+type requestIDKey struct{}
+
+// WithRequestID stores the request ID in ctx for Ctx to pick up.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Ctx returns the logger annotated with the request ID and the trace and span
+// IDs from ctx, so log lines can be joined with the request that produced them.
+func (l *Logger) Ctx(ctx context.Context) *zap.SugaredLogger {
+	return withContext(l.Sugar, ctx)
+}
+
+// Sampled is Ctx for high-volume lines such as successful checks: it applies
+// logging.sampling, which the other lines, errors included, are exempt from.
+func (l *Logger) Sampled(ctx context.Context) *zap.SugaredLogger {
+	return withContext(l.sampled, ctx)
+}
+
+func withContext(log *zap.SugaredLogger, ctx context.Context) *zap.SugaredLogger {
+	var fields []interface{}
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, "request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	if len(fields) == 0 {
+		return log
+	}
+	return log.With(fields...)
+}
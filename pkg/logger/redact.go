@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	redactedPlaceholder = "[REDACTED]"
+	minSecretLength     = 4
+)
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+func init() {
+	zap.RegisterEncoder("redacted-console", func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return redactingEncoder{zapcore.NewConsoleEncoder(cfg)}, nil
+	})
+	zap.RegisterEncoder("redacted-json", func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return redactingEncoder{zapcore.NewJSONEncoder(cfg)}, nil
+	})
+}
+
+func RegisterSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+
+	if escaped, err := json.Marshal(secret); err == nil {
+		if e := string(escaped[1 : len(escaped)-1]); e != secret {
+			secrets = append(secrets, e)
+		}
+	}
+}
+
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redactedPlaceholder)
+	}
+	return s
+}
+
+type redactingEncoder struct {
+	zapcore.Encoder
+}
+
+func (e redactingEncoder) Clone() zapcore.Encoder {
+	return redactingEncoder{e.Encoder.Clone()}
+}
+
+func (e redactingEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	line := buf.String()
+	redacted := Redact(line)
+	if redacted != line {
+		buf.Reset()
+		buf.AppendString(redacted)
+	}
+	return buf, nil
+}
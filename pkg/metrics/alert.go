@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var AlertRegistry = prometheus.NewRegistry()
+
+var (
+	alertFactory = promauto.With(AlertRegistry)
+
+	AlertMessagesConsumed = alertFactory.NewCounter(prometheus.CounterOpts{
+		Name: "alert_messages_consumed_total",
+		Help: "Total number of messages consumed from Kafka",
+	})
+
+	AlertParseErrors = alertFactory.NewCounter(prometheus.CounterOpts{
+		Name: "alert_parse_errors_total",
+		Help: "Total number of messages that failed to parse",
+	})
+
+	AlertsSent = alertFactory.NewCounterVec(prometheus.CounterOpts{
+		Name: "alerts_sent_total",
+		Help: "Total number of alerts sent",
+	}, []string{"notifier"})
+
+	AlertsFailed = alertFactory.NewCounterVec(prometheus.CounterOpts{
+		Name: "alerts_failed_total",
+		Help: "Total number of alerts that failed to send",
+	}, []string{"notifier"})
+
+	AlertNotifierDuration = alertFactory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "alert_notifier_duration_seconds",
+		Help:    "Duration of notifier calls in seconds",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"notifier"})
+
+	AlertRedisErrors = alertFactory.NewCounter(prometheus.CounterOpts{
+		Name: "alert_redis_errors_total",
+		Help: "Total number of Redis errors",
+	})
+
+	AlertConsumerLag = alertFactory.NewGauge(prometheus.GaugeOpts{
+		Name: "alert_kafka_consumer_lag",
+		Help: "Number of messages the consumer lags behind the end of the topic",
+	})
+)
+
+func init() {
+	AlertRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
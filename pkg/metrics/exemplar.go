@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ObserveWithTrace records v and attaches the sampled trace ID from ctx as an
+// exemplar, so a slow bucket in Grafana links to the trace that caused it.
+func ObserveWithTrace(ctx context.Context, o prometheus.Observer, v float64) {
+	sc := trace.SpanContextFromContext(ctx)
+	eo, ok := o.(prometheus.ExemplarObserver)
+	if !ok || !sc.IsSampled() {
+		o.Observe(v)
+		return
+	}
+	eo.ObserveWithExemplar(v, prometheus.Labels{"trace_id": sc.TraceID().String()})
+}
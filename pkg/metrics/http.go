@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var CrudRegistry = prometheus.NewRegistry()
+
+var (
+	crudFactory = promauto.With(CrudRegistry)
+
+	HTTPRequestsTotal = crudFactory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests by route pattern",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = crudFactory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests in seconds by route pattern",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	}, []string{"method", "route"})
+
+	AuditFailures = crudFactory.NewCounterVec(prometheus.CounterOpts{
+		Name: "audit_failures_total",
+		Help: "Total number of audit events that could not be stored or streamed",
+	}, []string{"sink"})
+)
+
+func init() {
+	CrudRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
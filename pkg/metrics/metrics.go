@@ -47,4 +47,44 @@ var (
 		Name: "checker_api_errors_total",
 		Help: "Total number of API fetch errors",
 	})
+
+	CheckerUp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "checker_up",
+		Help: "Whether the checker is running (1 = running, 0 = stopped gracefully); stays 1 in Pushgateway after a crash",
+	})
+
+	CheckerLastCycleTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "checker_last_cycle_timestamp_seconds",
+		Help: "Unix time of the last completed checker cycle",
+	})
 )
+
+// SiteInfoLabel is the label a site label key is exported as on site_info.
+func SiteInfoLabel(key string) string {
+	return "label_" + key
+}
+
+// NewSiteInfo registers site_info, which is always 1 and carries the site's
+// metadata for joins like `site_check_success * on(url) group_left(owner)
+// site_info`. labelKeys are the allowlisted site labels; they are fixed for
+// the life of the process.
+func NewSiteInfo(labelKeys []string) *prometheus.GaugeVec {
+	names := []string{"url", "name", "owner", "team"}
+	for _, k := range labelKeys {
+		names = append(names, SiteInfoLabel(k))
+	}
+	return promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "site_info",
+		Help: "Site metadata, always 1",
+	}, names)
+}
+
+// DeleteSiteSeries removes every per-site series for url, so sites deleted
+// from the API stop being reported.
+func DeleteSiteSeries(url string) {
+	labels := prometheus.Labels{"url": url}
+	SiteCheckTotal.DeletePartialMatch(labels)
+	SiteCheckDuration.DeletePartialMatch(labels)
+	SiteCheckSuccess.DeletePartialMatch(labels)
+	SiteCheckErrors.DeletePartialMatch(labels)
+}
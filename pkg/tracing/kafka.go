@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+)
+
+type kafkaHeaders struct {
+	headers *[]kafka.Header
+}
+
+func (k kafkaHeaders) Get(key string) string {
+	for _, h := range *k.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (k kafkaHeaders) Set(key, value string) {
+	for i, h := range *k.headers {
+		if h.Key == key {
+			(*k.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*k.headers = append(*k.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (k kafkaHeaders) Keys() []string {
+	keys := make([]string, 0, len(*k.headers))
+	for _, h := range *k.headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// InjectKafka writes the trace context from ctx into the message headers.
+func InjectKafka(ctx context.Context, m *kafka.Message) {
+	otel.GetTextMapPropagator().Inject(ctx, kafkaHeaders{headers: &m.Headers})
+}
+
+// ExtractKafka returns ctx with the trace context carried by the message headers.
+func ExtractKafka(ctx context.Context, m kafka.Message) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, kafkaHeaders{headers: &m.Headers})
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"site-monitor/pkg/logger"
+)
+
+func RunHTTPServer(ctx context.Context, srv *http.Server, log *logger.Logger) {
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Sugar.Errorw("HTTP server Shutdown failed", "error", err)
+		}
+	}()
+
+	log.Sugar.Infow("HTTP server started", "addr", srv.Addr)
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Sugar.Errorw("HTTP server ListenAndServe failed", "error", err)
+	}
+
+	log.Sugar.Infow("HTTP server stopped gracefully", "addr", srv.Addr)
+}
+
+func WriteJSON(log *logger.Logger, w http.ResponseWriter, v any, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Sugar.Errorw("Failed to write JSON response", "error", err)
+	}
+}
+
+// RequireToken lets through only requests that carry token as a bearer token.
+// It guards the admin endpoints of services that have no API keys of their own.
+func RequireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				WriteProblem(w, r, http.StatusUnauthorized, "missing or invalid admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// The cases are the examples of RFC 7396, appendix A, plus the ones the
+// sites PATCH relies on.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"remove one of two", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"array replaces", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"value replaces array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"arrays are not merged", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"non-object doc", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"object replaces array doc", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"null patch", `{"a":"foo"}`, `null`, `null`},
+		{"string patch", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null inside new member", `{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{"array doc patched by object", `[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{"nested null in new object", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{"labels merge", `{"labels":{"env":"prod","tier":"1"}}`, `{"labels":{"tier":null,"team":"web"}}`,
+			`{"labels":{"env":"prod","team":"web"}}`},
+		{"empty patch keeps doc", `{"url":"https://a.example","active":true}`, `{}`, `{"url":"https://a.example","active":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("MergePatch(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchInvalidJSON(t *testing.T) {
+	for _, tt := range []struct{ doc, patch string }{
+		{`{`, `{}`},
+		{`{}`, `{"a":`},
+	} {
+		if _, err := MergePatch([]byte(tt.doc), []byte(tt.patch)); err == nil {
+			t.Errorf("MergePatch(%s, %s): expected an error", tt.doc, tt.patch)
+		}
+	}
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("unmarshal %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("unmarshal %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"site-monitor/pkg/logger"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error body. Type is always about:blank, so Title is
+// the standard status text and Detail carries the specific reason.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewProblem describes status for r. An empty detail leaves only the status
+// text, which is what 500s should expose.
+func NewProblem(r *http.Request, status int, detail string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: logger.RequestID(r.Context()),
+	}
+}
+
+// WriteProblem writes an application/problem+json response for r.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	WriteProblemBody(w, status, NewProblem(r, status, detail))
+}
+
+// WriteProblemBody writes body, a Problem with extension members embedded
+// next to it, as application/problem+json.
+func WriteProblemBody(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
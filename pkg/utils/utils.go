@@ -2,9 +2,12 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"site-monitor/pkg/logger"
 )
@@ -18,3 +21,47 @@ func SetupGracefulShutdown(cancelFunc context.CancelFunc, log *logger.Logger) {
 		cancelFunc()
 	}()
 }
+
+func SetupConfigReload(ctx context.Context, path string, pollInterval time.Duration, reload func() error, log *logger.Logger) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		last := fileFingerprint(path)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+				log.Sugar.Infow("Reload signal received", "path", path)
+			case <-ticker.C:
+				current := fileFingerprint(path)
+				if current == "" || current == last {
+					continue
+				}
+				log.Sugar.Infow("Config file changed", "path", path)
+			}
+
+			last = fileFingerprint(path)
+			if err := reload(); err != nil {
+				log.Sugar.Errorw("Config reload rejected, keeping current config", "path", path, "error", err)
+				continue
+			}
+			log.Sugar.Infow("Config reloaded", "path", path)
+		}
+	}()
+}
+
+func fileFingerprint(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}